@@ -0,0 +1,203 @@
+package kelm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"kelm/internal/pkg/notify"
+
+	"github.com/sirupsen/logrus"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+// CloudEvents emitter, nil when NOTIFY_CLOUDEVENTS_URL is not set
+var emitter *notify.CloudEvents
+
+// Last emitted deadline per env, an env missing here and in kelm.riftonix.io/status.emitted gets a registered event
+var emittedDeadlines = make(map[string]time.Time)
+var emittedDeadlinesMu sync.Mutex
+
+// emittedStatus is kept in kelm.riftonix.io/status.emitted, so restarts and leader changes do not repeat events
+type emittedStatus struct {
+	ExpiresAt time.Time `json:"expiresAt,omitzero"`
+}
+
+// envEventData - CloudEvent payload built from Env
+type envEventData struct {
+	Env                 string                   `json:"env"`
+	Namespaces          []string                 `json:"namespaces"`
+	Aggregation         string                   `json:"aggregation"`
+	Clock               string                   `json:"clock"`
+	CreationTimestamp   time.Time                `json:"creationTimestamp"`
+	AnchorTimestamp     time.Time                `json:"anchorTimestamp"`
+	ExpiresAt           time.Time                `json:"expiresAt"`
+	NotificationFactors []float64                `json:"notificationFactors,omitempty"`
+	Factor              float64                  `json:"factor,omitempty"`
+	Hold                bool                     `json:"hold"`
+	Owners              []string                 `json:"owners,omitempty"`
+	Channels            []string                 `json:"channels,omitempty"`
+	ZarfPackage         string                   `json:"zarfPackage,omitempty"`
+	Results             []notify.NamespaceReport `json:"results,omitempty"`
+	Zarf                *notify.ZarfReport       `json:"zarf,omitempty"`
+	RetryAt             time.Time                `json:"retryAt,omitzero"`
+}
+
+func getCloudEventsMode() string {
+	mode := os.Getenv("NOTIFY_CLOUDEVENTS_MODE")
+	switch mode {
+	case "":
+		return notify.ModeBinary
+	case notify.ModeBinary, notify.ModeStructured:
+		return mode
+	}
+	logrus.Warnf("Invalid NOTIFY_CLOUDEVENTS_MODE %q, using %q", mode, notify.ModeBinary)
+	return notify.ModeBinary
+}
+
+func getCloudEventsSource() string {
+	source := os.Getenv("NOTIFY_CLOUDEVENTS_SOURCE")
+	if source == "" {
+		return "kelm"
+	}
+	return source
+}
+
+func getEmitter() *notify.CloudEvents {
+	url := os.Getenv("NOTIFY_CLOUDEVENTS_URL")
+	if url == "" {
+		logrus.Info("CloudEvents disabled: NOTIFY_CLOUDEVENTS_URL is not set")
+		return nil
+	}
+	logrus.Infof("CloudEvents enabled: %s mode, source %s", getCloudEventsMode(), getCloudEventsSource())
+	return notify.NewCloudEvents(url, getCloudEventsSource(), getCloudEventsMode(), getNotifyTimeout(), getNotifyRetries(), getNotifyRetryDelay())
+}
+
+func newEnvEventData(env Env) envEventData {
+	return envEventData{
+		Env:                 env.Name,
+		Namespaces:          env.Namespaces,
+		Aggregation:         env.Aggregation,
+		Clock:               env.Clock,
+		CreationTimestamp:   env.CreationTimestamp,
+		AnchorTimestamp:     env.AnchorTimestamp,
+		ExpiresAt:           env.ExpiresAt,
+		NotificationFactors: env.NotificationFactors,
+		Hold:                env.Hold,
+		Owners:              env.OwnerEmails,
+		Channels:            env.NotifyChannels,
+		ZarfPackage:         env.ZarfPackageName,
+	}
+}
+
+func emitEnvEvent(eventType string, env Env, data envEventData) {
+	if emitter == nil {
+		return
+	}
+	event := notify.CloudEvent{Type: eventType, Subject: env.Name, Time: time.Now(), Data: data}
+	if err := emitter.Emit(context.Background(), event); err != nil {
+		logrus.Errorf("Failed to emit %s for env '%s': %v", eventType, env.Name, err)
+		return
+	}
+	logrus.Debugf("Emitted %s for env '%s'", eventType, env.Name)
+}
+
+// emitEnvScheduled emits registered for an env seen the first time and extended when its deadline moved later.
+// Held envs move their deadline on every resync, so they are not reported as extended.
+// The emitted deadline is recorded on the env namespaces, it returns the failed writes.
+func emitEnvScheduled(client kubernetes.Interface, env Env) error {
+	if emitter == nil {
+		return nil
+	}
+	emittedDeadlinesMu.Lock()
+	previous, known := emittedDeadlines[env.Name]
+	if !known {
+		// A new operator process takes the deadline its predecessor emitted
+		previous, known = env.EmittedDeadline, env.Emitted
+	}
+	emittedDeadlines[env.Name] = env.ExpiresAt
+	emittedDeadlinesMu.Unlock()
+	switch {
+	case !known:
+		go emitEnvEvent(notify.EventRegistered, env, newEnvEventData(env))
+		return recordEmitted(client, env, env.Namespaces)
+	case !env.Hold && env.ExpiresAt.After(previous):
+		go emitEnvEvent(notify.EventExtended, env, newEnvEventData(env))
+		return recordEmitted(client, env, env.Namespaces)
+	}
+	// Namespaces that joined later or missed a write
+	return recordEmitted(client, env, env.UnemittedNamespaces)
+}
+
+// parseEmittedStatus returns the deadline emitted for the namespace env, nil if none was
+func parseEmittedStatus(ns core.Namespace) *emittedStatus {
+	s, ok := ns.Annotations["kelm.riftonix.io/status.emitted"]
+	if !ok {
+		return nil
+	}
+	var status emittedStatus
+	// Status is written by kelm, a broken one only means a registered event may repeat
+	if err := json.Unmarshal([]byte(s), &status); err != nil {
+		logrus.Warnf("Ignoring namespace %s annotation kelm.riftonix.io/status.emitted '%s': %v", ns.Name, s, err)
+		return nil
+	}
+	status.ExpiresAt = status.ExpiresAt.UTC()
+	return &status
+}
+
+// recordEmitted stores the emitted deadline of the env on namespaces
+func recordEmitted(client kubernetes.Interface, env Env, namespaces []string) error {
+	if len(namespaces) == 0 {
+		return nil
+	}
+	value, err := json.Marshal(emittedStatus{ExpiresAt: env.ExpiresAt})
+	if err != nil {
+		return fmt.Errorf("encode emitted status of env '%s': %w", env.Name, err)
+	}
+	patch := fmt.Sprintf(`{"metadata":{"annotations":{"kelm.riftonix.io/status.emitted":%q}}}`, value)
+	var errs []error
+	for _, ns := range namespaces {
+		_, err := client.CoreV1().Namespaces().Patch(context.Background(), ns, types.MergePatchType, []byte(patch), meta.PatchOptions{})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("record emitted status on namespace %s: %w", ns, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func forgetEmittedEnv(envName string) {
+	emittedDeadlinesMu.Lock()
+	defer emittedDeadlinesMu.Unlock()
+	delete(emittedDeadlines, envName)
+}
+
+func emitEnvWarning(env Env, factor float64) {
+	data := newEnvEventData(env)
+	data.Factor = factor
+	emitEnvEvent(notify.EventWarning, env, data)
+}
+
+func emitEnvExpired(env Env) {
+	emitEnvEvent(notify.EventExpired, env, newEnvEventData(env))
+}
+
+// emitEnvDeleteResults emits deleted, or deletion_failed when the report has a retry time
+func emitEnvDeleteResults(env Env, report notify.TeardownReport) {
+	data := newEnvEventData(env)
+	data.Results = report.Namespaces
+	data.Zarf = report.Zarf
+	if report.RetryAt.IsZero() {
+		forgetEmittedEnv(env.Name)
+		emitEnvEvent(notify.EventDeleted, env, data)
+		return
+	}
+	data.RetryAt = report.RetryAt
+	emitEnvEvent(notify.EventDeletionFailed, env, data)
+}
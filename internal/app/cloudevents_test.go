@@ -0,0 +1,123 @@
+package kelm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"kelm/internal/pkg/k8s"
+	"kelm/internal/pkg/notify"
+
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// useEmitter points the emitter at a test server and returns the received event types
+func useEmitter(t *testing.T) chan string {
+	types := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		types <- r.Header.Get("ce-type")
+	}))
+	t.Cleanup(server.Close)
+	previous := emitter
+	emitter = notify.NewCloudEvents(server.URL, "kelm", notify.ModeBinary, time.Second, 0, 0)
+	t.Cleanup(func() { emitter = previous })
+	return types
+}
+
+func expectEventType(t *testing.T, types chan string, expected string) {
+	t.Helper()
+	select {
+	case eventType := <-types:
+		if eventType != expected {
+			t.Errorf("Expected %s, got %s", expected, eventType)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Expected %s, got nothing", expected)
+	}
+}
+
+func expectNoEvent(t *testing.T, types chan string) {
+	t.Helper()
+	select {
+	case eventType := <-types:
+		t.Errorf("Expected no event, got %s", eventType)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestEmitEnvScheduled(t *testing.T) {
+	types := useEmitter(t)
+	forgetEmittedEnv("env1")
+	t.Cleanup(func() { forgetEmittedEnv("env1") })
+	client := fake.NewSimpleClientset(&core.Namespace{ObjectMeta: meta.ObjectMeta{Name: "ns1"}})
+	env := Env{Name: "env1", Namespaces: []string{"ns1"}, ExpiresAt: time.Now().Add(time.Hour)}
+
+	emitEnvScheduled(client, env)
+	expectEventType(t, types, notify.EventRegistered)
+	emitEnvScheduled(client, env)
+	expectNoEvent(t, types)
+
+	env.ExpiresAt = env.ExpiresAt.Add(time.Hour)
+	emitEnvScheduled(client, env)
+	expectEventType(t, types, notify.EventExtended)
+
+	env.Hold = true
+	env.ExpiresAt = env.ExpiresAt.Add(time.Minute)
+	emitEnvScheduled(client, env)
+	expectNoEvent(t, types)
+}
+
+func TestEmitEnvScheduledAfterRestart(t *testing.T) {
+	types := useEmitter(t)
+	forgetEmittedEnv("env1")
+	t.Cleanup(func() { forgetEmittedEnv("env1") })
+	client := fake.NewSimpleClientset(&core.Namespace{ObjectMeta: meta.ObjectMeta{Name: "ns1"}})
+	deadline := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	env := Env{Name: "env1", Namespaces: []string{"ns1"}, ExpiresAt: deadline}
+
+	if err := emitEnvScheduled(client, env); err != nil {
+		t.Fatalf("Expected emitted status to be recorded, got %v", err)
+	}
+	expectEventType(t, types, notify.EventRegistered)
+	ns, err := client.CoreV1().Namespaces().Get(context.Background(), "ns1", meta.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected namespace, got %v", err)
+	}
+	status := parseEmittedStatus(*ns)
+	if status == nil || !status.ExpiresAt.Equal(deadline) {
+		t.Fatalf("Expected emitted deadline %v to be recorded, got %+v", deadline, status)
+	}
+
+	// A new operator process knows the env only from the recorded status
+	forgetEmittedEnv("env1")
+	env.Emitted, env.EmittedDeadline = true, status.ExpiresAt
+	emitEnvScheduled(client, env)
+	expectNoEvent(t, types)
+
+	forgetEmittedEnv("env1")
+	env.ExpiresAt = deadline.Add(time.Hour)
+	emitEnvScheduled(client, env)
+	expectEventType(t, types, notify.EventExtended)
+}
+
+func TestEmitEnvDeleteResults(t *testing.T) {
+	types := useEmitter(t)
+	env := Env{Name: "env1", Namespaces: []string{"ns1"}}
+	report := notify.TeardownReport{
+		Namespaces: newNamespaceReports([]k8s.NamespaceDeleteResult{{Namespace: "ns1", State: "error", DeletionError: errors.New("forbidden")}}),
+		RetryAt:    time.Now().Add(time.Hour),
+	}
+
+	emitEnvDeleteResults(env, report)
+	expectEventType(t, types, notify.EventDeletionFailed)
+
+	report.Namespaces[0].State = "deleted"
+	report.RetryAt = time.Time{}
+	emitEnvDeleteResults(env, report)
+	expectEventType(t, types, notify.EventDeleted)
+}
@@ -2,52 +2,156 @@ package kelm
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
 	"time"
 
+	"kelm/internal/pkg/timer"
+
 	"github.com/sirupsen/logrus"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
 )
 
-type CountdownResult int
+// CountdownCallback runs when a countdown expires with the env namespaces
+type CountdownCallback func(namespaces []string)
 
+// Countdown scenarios
 const (
-	ExpiredState CountdownResult = iota
-	CancelledState
-	InvalidTTLState
+	scenarioRemoval      = "removal"
+	scenarioNotification = "notification"
 )
 
-type DeleteNamespacesCallback func(namespaces []string)
-
-func CreateCountdown(
-	ctx context.Context,
-	env Env,
-	ttlSeconds int,
-	scenario string,
-	deleteNamespaces DeleteNamespacesCallback,
-) CountdownResult {
-	if ttlSeconds <= 0 {
-		logrus.Debugf("Env '%s' TTL expired for scenario %s!", env.Name, scenario)
-		if scenario == "removal" && deleteNamespaces != nil {
-			logrus.Infof("Force deleting namespaces for env '%s': %v", env.Name, env.Namespaces)
-			deleteNamespaces(env.Namespaces)
-			return ExpiredState
-		}
-		return InvalidTTLState
-	}
-	timer := time.NewTimer(time.Duration(ttlSeconds) * time.Second)
-	defer timer.Stop() // Delayed timer cleanup
-
-	select {
-	case <-ctx.Done():
-		// Timer canceled
-		logrus.Debugf("Env '%s' TTL countdown cancelled for scenario %s.", env.Name, scenario)
-		return CancelledState
-	case <-timer.C:
-		// Env expired
-		logrus.Debugf("Env '%s' TTL expired after %d seconds for scenario %s!", env.Name, ttlSeconds, scenario)
-		if scenario == "removal" && deleteNamespaces != nil {
-			logrus.Infof("Force deleting namespaces for env '%s': %v", env.Name, env.Namespaces)
-			deleteNamespaces(env.Namespaces)
+// TTL anchor modes: which timestamp starts the removal countdown
+const (
+	anchorLatest   = "latest"
+	anchorCreation = "creation"
+	anchorUpdate   = "update"
+)
+
+func getTtlAnchorMode() string {
+	mode := os.Getenv("TTL_ANCHOR")
+	switch mode {
+	case "":
+		return anchorLatest
+	case anchorLatest, anchorCreation, anchorUpdate:
+		return mode
+	}
+	logrus.Warnf("Invalid TTL_ANCHOR %q, using %q", mode, anchorLatest)
+	return anchorLatest
+}
+
+// TTL clocks: which time counts towards the TTL
+const (
+	clockWall     = "wall"
+	clockBusiness = "business"
+)
+
+const defaultBusinessHours = "Mon-Fri 09:00-18:00 UTC"
+
+func getBusinessCalendar() timer.BusinessCalendar {
+	spec := os.Getenv("BUSINESS_HOURS")
+	if spec == "" {
+		spec = defaultBusinessHours
+	}
+	calendar, err := timer.ParseBusinessHours(spec, os.Getenv("BUSINESS_HOLIDAYS"))
+	if err != nil {
+		logrus.Warnf("Invalid BUSINESS_HOURS %q or BUSINESS_HOLIDAYS, using %q: %v", spec, defaultBusinessHours, err)
+		calendar, _ = timer.ParseBusinessHours(defaultBusinessHours, "")
+	}
+	return calendar
+}
+
+// getTtlClock returns the clock for kelm.riftonix.io/ttl.clock value
+func getTtlClock(name string) timer.Clock {
+	if name == clockBusiness {
+		return getBusinessCalendar()
+	}
+	return timer.WallClock{}
+}
+
+// replenishedStatus - value of kelm.riftonix.io/status.replenished, the anchor moved by the last counted update
+type replenishedStatus struct {
+	Anchor time.Time `json:"anchor"`
+	Update time.Time `json:"update"`
+}
+
+func parseReplenishedStatus(ns core.Namespace) replenishedStatus {
+	var status replenishedStatus
+	s := ns.Annotations["kelm.riftonix.io/status.replenished"]
+	if s == "" {
+		return status
+	}
+	// Status is written by kelm, a broken one only means the anchor is counted from creation again
+	if err := json.Unmarshal([]byte(s), &status); err != nil {
+		logrus.Warnf("Ignoring namespace %s annotation kelm.riftonix.io/status.replenished '%s': %v", ns.Name, s, err)
+		return replenishedStatus{}
+	}
+	status.Anchor, status.Update = status.Anchor.UTC(), status.Update.UTC()
+	return status
+}
+
+// getTtlAnchor returns the time from which env TTL and notification offsets are counted,
+// and the replenishment status to keep on the env namespaces.
+// By default the group-max updateTimestamp replenishes the TTL according to ReplenishRatio,
+// measured from the anchor the last counted update left behind.
+func getTtlAnchor(rawEnv RawEnv, clock timer.Clock) (time.Time, replenishedStatus) {
+	status := rawEnv.Replenished
+	switch getTtlAnchorMode() {
+	case anchorCreation:
+		return rawEnv.CreationTimestamp, status
+	case anchorUpdate:
+		return rawEnv.UpdateTimestamp, status
+	}
+	if rawEnv.Ttl == "" {
+		// Deadline-only env, there is no TTL to replenish
+		return timer.GetMaxTime(rawEnv.CreationTimestamp, rawEnv.UpdateTimestamp), status
+	}
+	anchor := timer.GetMaxTime(rawEnv.CreationTimestamp, status.Anchor)
+	if !rawEnv.UpdateTimestamp.After(status.Update) {
+		// The update was already counted
+		return anchor, status
+	}
+	replenished, err := timer.GetReplenishedAnchor(clock, anchor, rawEnv.UpdateTimestamp, rawEnv.Ttl, rawEnv.ReplenishRatio)
+	if err != nil {
+		// You should not see this log, rawEnvPart already validated
+		logrus.Warningf("Failed to replenish ttl in %s: %v", rawEnv.Name, err)
+		return anchor, status
+	}
+	if replenished.Equal(anchor) {
+		return anchor, status
+	}
+	return replenished, replenishedStatus{Anchor: replenished, Update: rawEnv.UpdateTimestamp}
+}
+
+// recordReplenished stores the replenished anchor on env namespaces that miss it,
+// so a later update is measured from it after resyncs and operator restarts.
+func recordReplenished(client kubernetes.Interface, env Env) error {
+	if len(env.UnrecordedReplenishedNamespaces) == 0 {
+		return nil
+	}
+	value, err := json.Marshal(env.Replenished)
+	if err != nil {
+		return fmt.Errorf("encode replenishment status of env '%s': %w", env.Name, err)
+	}
+	patch := fmt.Sprintf(`{"metadata":{"annotations":{"kelm.riftonix.io/status.replenished":%q}}}`, value)
+	var errs []error
+	for _, ns := range env.UnrecordedReplenishedNamespaces {
+		_, err := client.CoreV1().Namespaces().Patch(context.Background(), ns, types.MergePatchType, []byte(patch), meta.PatchOptions{})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("record replenishment status on namespace %s: %w", ns, err))
+			continue
 		}
-		return ExpiredState
+		logrus.Infof("Env '%s' TTL replenished from %s, anchor %s recorded on namespace %s", env.Name, env.Replenished.Update.Format(time.RFC3339), env.Replenished.Anchor.Format(time.RFC3339), ns)
 	}
+	return errors.Join(errs...)
+}
+
+// isEnvHeld reports whether the env is on hold without an end, so it has no deadline at all
+func isEnvHeld(env Env) bool {
+	return env.Hold && env.ExpiresAt.IsZero()
 }
@@ -1,60 +1,61 @@
 package kelm
 
 import (
-	"context"
 	"testing"
 	"time"
-)
 
-func TestCreateCountdown(t *testing.T) {
-	// 1. Invalid TTL (<= 0)
-	ctx := context.Background()
-	env := Env{Name: "env1", Namespaces: []string{"ns1", "ns2"}}
-	if result := CreateCountdown(ctx, env, 0, "sc1", nil); result != InvalidTTLState {
-		t.Errorf("Expected InvalidTTLState for ttlSeconds=0, got %v", result)
-	}
-	if result := CreateCountdown(ctx, env, -5, "sc2", nil); result != InvalidTTLState {
-		t.Errorf("Expected InvalidTTLState for ttlSeconds=-5, got %v", result)
-	}
+	"kelm/internal/pkg/timer"
+)
 
-	var expiredDeleted []string
-	if result := CreateCountdown(ctx, env, 0, "removal", func(namespaces []string) {
-		expiredDeleted = append(expiredDeleted, namespaces...)
-	}); result != ExpiredState {
-		t.Errorf("Expected ExpiredState for expired removal countdown, got %v", result)
-	}
-	if len(expiredDeleted) != 2 || expiredDeleted[0] != "ns1" || expiredDeleted[1] != "ns2" {
-		t.Errorf("Expected expired callback to be called with namespaces, got %v", expiredDeleted)
-	}
+func TestGetTtlAnchor(t *testing.T) {
+	creation := time.Now().Add(-2 * time.Hour).UTC()
+	update := time.Now().Add(-1 * time.Hour).UTC()
+	rawEnv := RawEnv{Ttl: "90m", CreationTimestamp: creation, UpdateTimestamp: update}
+	staleRawEnv := RawEnv{Ttl: "90m", CreationTimestamp: update, UpdateTimestamp: creation}
+	// Update arrives after 1h of 90m ttl: counts for ratio 0.5, ignored for ratio 0.75
+	replenishedRawEnv := RawEnv{Ttl: "90m", ReplenishRatio: 0.5, CreationTimestamp: creation, UpdateTimestamp: update}
+	earlyRawEnv := RawEnv{Ttl: "90m", ReplenishRatio: 0.75, CreationTimestamp: creation, UpdateTimestamp: update}
+	// The counted update moved the anchor to 45m, it is not counted twice
+	replenished := replenishedStatus{Anchor: creation.Add(45 * time.Minute), Update: update}
+	recordedRawEnv := RawEnv{Ttl: "90m", ReplenishRatio: 0.5, CreationTimestamp: creation, UpdateTimestamp: update, Replenished: replenished}
+	// The next update has to wait for 45m more from the recorded anchor
+	touchedRawEnv := RawEnv{Ttl: "90m", ReplenishRatio: 0.5, CreationTimestamp: creation, UpdateTimestamp: creation.Add(80 * time.Minute), Replenished: replenished}
+	retouchedRawEnv := RawEnv{Ttl: "90m", ReplenishRatio: 0.5, CreationTimestamp: creation, UpdateTimestamp: creation.Add(100 * time.Minute), Replenished: replenished}
 
-	// 2. Cancelled context before timer fires
-	ctx2, cancel := context.WithCancel(context.Background())
-	go func() {
-		time.Sleep(10 * time.Millisecond)
-		cancel()
-	}()
-	env2 := Env{Name: "env2", Namespaces: []string{"ns3"}}
-	result2 := CreateCountdown(ctx2, env2, 1, "sc3", nil)
-	if result2 != CancelledState {
-		t.Errorf("Expected CancelledState when context cancelled, got %v", result2)
+	tests := []struct {
+		name           string
+		mode           string
+		rawEnv         RawEnv
+		expected       time.Time
+		expectedStatus replenishedStatus
+	}{
+		{name: "default uses update", mode: "", rawEnv: rawEnv, expected: update, expectedStatus: replenishedStatus{Anchor: update, Update: update}},
+		{name: "default ignores stale update", mode: "", rawEnv: staleRawEnv, expected: update},
+		{name: "latest", mode: "latest", rawEnv: rawEnv, expected: update, expectedStatus: replenishedStatus{Anchor: update, Update: update}},
+		{name: "creation", mode: "creation", rawEnv: rawEnv, expected: creation},
+		{name: "update", mode: "update", rawEnv: staleRawEnv, expected: creation},
+		{name: "invalid falls back to latest", mode: "bad", rawEnv: rawEnv, expected: update, expectedStatus: replenishedStatus{Anchor: update, Update: update}},
+		{name: "update after replenish ratio", mode: "", rawEnv: replenishedRawEnv, expected: replenished.Anchor, expectedStatus: replenished},
+		{name: "update before replenish ratio", mode: "", rawEnv: earlyRawEnv, expected: creation},
+		{name: "update mode ignores replenish ratio", mode: "update", rawEnv: earlyRawEnv, expected: update},
+		{name: "recorded update", mode: "", rawEnv: recordedRawEnv, expected: replenished.Anchor, expectedStatus: replenished},
+		{name: "update before threshold of recorded anchor", mode: "", rawEnv: touchedRawEnv, expected: replenished.Anchor, expectedStatus: replenished},
+		{
+			name: "update after threshold of recorded anchor", mode: "", rawEnv: retouchedRawEnv, expected: creation.Add(90 * time.Minute),
+			expectedStatus: replenishedStatus{Anchor: creation.Add(90 * time.Minute), Update: creation.Add(100 * time.Minute)},
+		},
 	}
 
-	// 3. Timer expires normally
-	ctx3 := context.Background()
-	start := time.Now()
-	env3 := Env{Name: "env3", Namespaces: []string{"ns4", "ns5"}}
-	var deleted []string
-	result3 := CreateCountdown(ctx3, env3, 1, "removal", func(namespaces []string) {
-		deleted = append(deleted, namespaces...)
-	})
-	elapsed := time.Since(start)
-	if result3 != ExpiredState {
-		t.Errorf("Expected ExpiredState for normal timer expiry, got %v", result3)
-	}
-	if elapsed < time.Second || elapsed > 2*time.Second {
-		t.Errorf("Expected function to wait ~1s, elapsed: %v", elapsed)
-	}
-	if len(deleted) != 2 || deleted[0] != "ns4" || deleted[1] != "ns5" {
-		t.Errorf("Expected callback to be called with namespaces, got %v", deleted)
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Setenv("TTL_ANCHOR", testCase.mode)
+			got, status := getTtlAnchor(testCase.rawEnv, timer.WallClock{})
+			if !got.Equal(testCase.expected) {
+				t.Errorf("Expected anchor %v, got %v", testCase.expected, got)
+			}
+			if status != testCase.expectedStatus {
+				t.Errorf("Expected status %+v, got %+v", testCase.expectedStatus, status)
+			}
+		})
 	}
 }
@@ -1,9 +1,9 @@
 package kelm
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
+	"net/mail"
 	"os"
 	"slices"
 	"strconv"
@@ -14,9 +14,9 @@ import (
 
 	"github.com/sirupsen/logrus"
 	core "k8s.io/api/core/v1"
-	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
-	"k8s.io/client-go/kubernetes"
+	"k8s.io/apimachinery/pkg/types"
+	listers "k8s.io/client-go/listers/core/v1"
 )
 
 // 1 RawEnvPart = 1 namespace
@@ -30,34 +30,136 @@ type RawEnvPart struct {
 	NsData              core.Namespace
 	CreationTimestamp   time.Time
 	UpdateTimestamp     time.Time
-	IsZarf              bool
-	ZarfPackageName     string
+	ExpiresAt           time.Time
+	Schedule            string
+	MaxLifetime         time.Duration
+	Clock               string
+	Hold                bool
+	HoldSince           time.Time
+	HoldUntil           time.Time
+	HoldReason          string
+	HeldFor             time.Duration
+	Aggregation         string
+	IsLeader            bool
+	NotifyChannel       string
+	OwnerEmails         []string
+	NotifyRoutes        []string
+	NotifyTimezone      string
+	Notified            notifiedStatus
+	Replenished         replenishedStatus
+	DeletionInterrupted time.Time
+	DeletionStarted     time.Time
+	// Deadline last reported to the CloudEvents sink, nil if none was
+	Emitted *emittedStatus
+	// Env the namespace was registered with, from kelm.riftonix.io/status.registered
+	RegisteredEnv   string
+	IsZarf          bool
+	ZarfPackageName string
+	// Annotations that were missing and took operator defaults
+	DefaultedAnnotations []string
 }
 
 // 1 RawEnv = n namespaces
 type RawEnv struct {
-	Name                string
-	Namespaces          []core.Namespace
-	Ttl                 string `default:"0s"`
-	ReplenishRatio      float64
-	NotificationFactors []float64
-	CreationTimestamp   time.Time
-	UpdateTimestamp     time.Time
-	IsZarf              bool
-	ZarfPackageName     string
+	Name                   string
+	Namespaces             []core.Namespace
+	Aggregation            string
+	Ttl                    string `default:"0s"`
+	ReplenishRatio         float64
+	NotificationFactors    []float64
+	CreationTimestamp      time.Time
+	UpdateTimestamp        time.Time
+	ExpiresAt              time.Time
+	Schedules              []string
+	FirstCreationTimestamp time.Time
+	MaxLifetime            time.Duration
+	Clock                  string
+	Hold                   bool
+	HoldSince              time.Time
+	HoldUntil              time.Time
+	HoldReasons            []string
+	// Held namespaces without kelm.riftonix.io/hold.since, kelm records it for them
+	UnrecordedHoldNamespaces []string
+	// Namespaces whose hold ended while kelm.riftonix.io/hold.since is still set
+	ReleasedHoldNamespaces []string
+	ReleasedHoldSince      time.Time
+	ReleasedHoldEnd        time.Time
+	// Clock time the countdown was frozen by holds that already ended
+	HeldFor          time.Duration
+	NotifyChannels   []string
+	OwnerEmails      []string
+	NotifyRoutes     []string
+	NotifyTimezone   string
+	NotifiedStatuses []notifiedStatus
+	// Latest replenishment recorded in the group and what each namespace has recorded
+	Replenished         replenishedStatus
+	ReplenishedStatuses map[string]replenishedStatus
+	// Earliest shutdown that interrupted the env deletion
+	DeletionInterrupted time.Time
+	// Latest deletion start recorded by a leader that has not finished it
+	DeletionStarted time.Time
+	// Latest deadline reported to the CloudEvents sink and the namespaces that have not recorded one
+	Emitted             bool
+	EmittedDeadline     time.Time
+	UnemittedNamespaces []string
+	// Namespaces whose registration with the env is not recorded yet
+	UnregisteredNamespaces []string
+	IsZarf                 bool
+	ZarfPackageName        string
 }
 
 // 1 RawEnv = 1 Env; Env - resulted entity, needs for kelm.go
 type Env struct {
-	Name                      string
-	Namespaces                []string
+	Name       string
+	Namespaces []string
+	// Namespace UIDs by name, events refer to namespaces by them
+	NamespaceUIDs             map[string]types.UID
+	Aggregation               string
 	RemainingTtl              time.Duration
 	ReplenishRatio            float64
+	NotificationFactors       []float64
+	NotificationTimestamps    []time.Time
 	RemainingNotificationsTtl []time.Duration
 	CreationTimestamp         time.Time
 	UpdateTimestamp           time.Time
-	IsZarf                    bool
-	ZarfPackageName           string
+	AnchorTimestamp           time.Time
+	ExpiresAt                 time.Time
+	MaxLifetime               time.Duration
+	Clock                     string
+	Hold                      bool
+	HoldSince                 time.Time
+	HoldUntil                 time.Time
+	HoldReason                string
+	UnrecordedHoldNamespaces  []string
+	// Clock time the countdown was frozen by holds that already ended
+	HeldFor time.Duration
+	// Namespaces that keep kelm.riftonix.io/hold.since of an ended hold, kelm records HeldFor instead
+	ReleasedHoldNamespaces []string
+	NotifyChannels         []string
+	OwnerEmails            []string
+	// Notification sinks and targets like "chat:#team-a" or "email:owner", empty means every sink
+	NotifyRoutes []string
+	// Timezone of the env quiet hours, empty means the NOTIFY_QUIET_HOURS timezone
+	NotifyTimezone string
+	// Notification factors already delivered for the current AnchorTimestamp
+	NotifiedFactors []float64
+	// Deadline of the last expiring notification, zero if the env is not warned
+	WarnedDeadline time.Time
+	// Anchor moved by the last counted update and the namespaces that have not recorded it yet
+	Replenished                     replenishedStatus
+	UnrecordedReplenishedNamespaces []string
+	// Shutdown interrupted the env deletion, it is resumed at once
+	DeletionInterrupted time.Time
+	// A previous leader may still delete the env, a new deletion waits for it
+	DeletionStarted time.Time
+	// The env was reported to the CloudEvents sink, with this deadline
+	Emitted             bool
+	EmittedDeadline     time.Time
+	UnemittedNamespaces []string
+	// Namespaces whose registration with the env is not recorded yet
+	UnregisteredNamespaces []string
+	IsZarf                 bool
+	ZarfPackageName        string
 }
 
 func getIgnoredNamespaces() []string {
@@ -82,6 +184,55 @@ func getIgnoredNamespaces() []string {
 
 var ignoredNamespaces = getIgnoredNamespaces()
 
+// Env aggregation strategies: how namespace TTL settings are combined into the env ones
+const (
+	aggregationMax    = "max"
+	aggregationMin    = "min"
+	aggregationLeader = "leader"
+)
+
+// Operator defaults for optional namespace annotations
+const (
+	defaultReplenishRatio      = 0.5
+	defaultNotificationFactors = "[]"
+)
+
+// getDefaultTtl returns the TTL for namespaces without ttl.removal, expiresAt and ttl.schedule,
+// empty if DEFAULT_TTL is not set
+func getDefaultTtl() string {
+	ttl := getDurationEnv("DEFAULT_TTL", 0)
+	if ttl == 0 {
+		return ""
+	}
+	return timer.FormatDuration(ttl)
+}
+
+func getDefaultReplenishRatio() float64 {
+	s := os.Getenv("DEFAULT_REPLENISH_RATIO")
+	if s == "" {
+		return defaultReplenishRatio
+	}
+	ratio, err := strconv.ParseFloat(s, 64)
+	if err != nil || ratio < 0 {
+		logrus.Warnf("Invalid DEFAULT_REPLENISH_RATIO %q, using %v", s, defaultReplenishRatio)
+		return defaultReplenishRatio
+	}
+	return ratio
+}
+
+func getDefaultNotificationFactors() []float64 {
+	s := os.Getenv("DEFAULT_NOTIFICATION_FACTORS")
+	if s == "" {
+		s = defaultNotificationFactors
+	}
+	var factors []float64
+	if err := json.Unmarshal([]byte(s), &factors); err != nil {
+		logrus.Warnf("Invalid DEFAULT_NOTIFICATION_FACTORS %q, using %s: %v", s, defaultNotificationFactors, err)
+		return nil
+	}
+	return factors
+}
+
 func isZarfEnabled() bool {
 	return os.Getenv("ZARF_ENABLED") == "true"
 }
@@ -98,37 +249,134 @@ func handleNamespace(ns core.Namespace) (RawEnvPart, error) {
 	replenishRatio := ns.Annotations["kelm.riftonix.io/ttl.replenishRatio"]
 	notificationFactors := ns.Annotations["kelm.riftonix.io/ttl.notificationFactors"]
 	updateTimestamp := ns.Annotations["kelm.riftonix.io/updateTimestamp"]
+	expiresAt := ns.Annotations["kelm.riftonix.io/expiresAt"]
+	schedule := ns.Annotations["kelm.riftonix.io/ttl.schedule"]
+	maxLifetime := ns.Annotations["kelm.riftonix.io/ttl.maxLifetime"]
+	clock := ns.Annotations["kelm.riftonix.io/ttl.clock"]
+	hold := ns.Annotations["kelm.riftonix.io/hold"]
+	holdSince := ns.Annotations["kelm.riftonix.io/hold.since"]
+	holdUntil := ns.Annotations["kelm.riftonix.io/hold.until"]
+	aggregation := ns.Annotations["kelm.riftonix.io/env.aggregation"]
 	var rawEnvPart RawEnvPart
+	var defaulted []string
 	if isManaged != "true" {
 		return rawEnvPart, fmt.Errorf("namespace %s label kelm.riftonix.io/managed is not true", ns.Name)
 	}
 	if envName == "" {
 		return rawEnvPart, fmt.Errorf("namespace %s has empty label kelm.riftonix.io/env.name", ns.Name)
 	}
-	if ttl == "" {
-		return rawEnvPart, fmt.Errorf("namespace %s has empty annotation kelm.riftonix.io/ttl.removal", ns.Name)
+	if ttl == "" && expiresAt == "" && schedule == "" {
+		ttl = getDefaultTtl()
+		if ttl == "" {
+			return rawEnvPart, fmt.Errorf("namespace %s has empty annotations kelm.riftonix.io/ttl.removal, kelm.riftonix.io/expiresAt and kelm.riftonix.io/ttl.schedule, and DEFAULT_TTL is not set", ns.Name)
+		}
+		defaulted = append(defaulted, "kelm.riftonix.io/ttl.removal")
 	}
-	if replenishRatio == "" {
-		return rawEnvPart, fmt.Errorf("namespace %s has empty annotation kelm.riftonix.io/ttl.replenishRatio", ns.Name)
+	if ttl != "" {
+		if _, err := timer.ParseDuration(ttl); err != nil {
+			return rawEnvPart, fmt.Errorf("failed to parse namespace %s annotation kelm.riftonix.io/ttl.removal '%s': %w", ns.Name, ttl, err)
+		}
 	}
-	parsedReplenishRatio, err := strconv.ParseFloat(replenishRatio, 64)
-	if err != nil {
-		return rawEnvPart, fmt.Errorf("failed to parse namespace %s annotation kelm.riftonix.io/ttl.replenishRatio '%s': %w", ns.Name, replenishRatio, err)
+	var err error
+	parsedReplenishRatio := getDefaultReplenishRatio()
+	if replenishRatio == "" {
+		defaulted = append(defaulted, "kelm.riftonix.io/ttl.replenishRatio")
+	} else {
+		parsedReplenishRatio, err = strconv.ParseFloat(replenishRatio, 64)
+		if err != nil {
+			return rawEnvPart, fmt.Errorf("failed to parse namespace %s annotation kelm.riftonix.io/ttl.replenishRatio '%s': %w", ns.Name, replenishRatio, err)
+		}
 	}
+	unmarshaledNotificationFactors := getDefaultNotificationFactors()
 	if notificationFactors == "" {
-		return rawEnvPart, fmt.Errorf("namespace %s has empty annotation kelm.riftonix.io/ttl.notificationFactors", ns.Name)
+		defaulted = append(defaulted, "kelm.riftonix.io/ttl.notificationFactors")
+	} else {
+		unmarshaledNotificationFactors = nil
+		err = json.Unmarshal([]byte(notificationFactors), &unmarshaledNotificationFactors)
+		if err != nil {
+			return rawEnvPart, fmt.Errorf("failed to parse namespace %s annotation kelm.riftonix.io/ttl.notificationFactors '%s': %w", ns.Name, notificationFactors, err)
+		}
 	}
+	// Without updateTimestamp the namespace was never extended
+	parsedUpdateTimestamp := ns.CreationTimestamp.Time.UTC()
 	if updateTimestamp == "" {
-		return rawEnvPart, fmt.Errorf("namespace %s has empty annotation kelm.riftonix.io/updateTimestamp", ns.Name)
+		defaulted = append(defaulted, "kelm.riftonix.io/updateTimestamp")
+	} else {
+		parsedUpdateTimestamp, err = timer.ParseTime(updateTimestamp)
+		if err != nil {
+			return rawEnvPart, fmt.Errorf("failed to parse namespace %s annotation kelm.riftonix.io/updateTimestamp '%s': %w", ns.Name, updateTimestamp, err)
+		}
 	}
-	parsedUpdateTimestamp, err := timer.ParseTime(updateTimestamp)
-	if err != nil {
-		return rawEnvPart, fmt.Errorf("failed to parse namespace %s annotation kelm.riftonix.io/updateTimestamp '%s': %w", ns.Name, updateTimestamp, err)
+	var parsedExpiresAt time.Time
+	if expiresAt != "" {
+		parsedExpiresAt, err = timer.ParseTime(expiresAt)
+		if err != nil {
+			return rawEnvPart, fmt.Errorf("failed to parse namespace %s annotation kelm.riftonix.io/expiresAt '%s': %w", ns.Name, expiresAt, err)
+		}
+	}
+	if schedule != "" {
+		parsedSchedule, err := timer.ParseSchedule(schedule)
+		if err != nil {
+			return rawEnvPart, fmt.Errorf("failed to parse namespace %s annotation kelm.riftonix.io/ttl.schedule '%s': %w", ns.Name, schedule, err)
+		}
+		if parsedSchedule.Next(time.Now()).IsZero() {
+			return rawEnvPart, fmt.Errorf("namespace %s annotation kelm.riftonix.io/ttl.schedule '%s' never fires", ns.Name, schedule)
+		}
+	}
+	var parsedMaxLifetime time.Duration
+	if maxLifetime != "" {
+		parsedMaxLifetime, err = timer.ParseDuration(maxLifetime)
+		if err != nil {
+			return rawEnvPart, fmt.Errorf("failed to parse namespace %s annotation kelm.riftonix.io/ttl.maxLifetime '%s': %w", ns.Name, maxLifetime, err)
+		}
+		if parsedMaxLifetime <= 0 {
+			return rawEnvPart, fmt.Errorf("namespace %s annotation kelm.riftonix.io/ttl.maxLifetime '%s' must be positive", ns.Name, maxLifetime)
+		}
+	}
+	if clock == "" {
+		clock = clockWall
+	}
+	if clock != clockWall && clock != clockBusiness {
+		return rawEnvPart, fmt.Errorf("namespace %s annotation kelm.riftonix.io/ttl.clock '%s' must be '%s' or '%s'", ns.Name, clock, clockWall, clockBusiness)
+	}
+	if aggregation != "" && aggregation != aggregationMax && aggregation != aggregationMin && aggregation != aggregationLeader {
+		return rawEnvPart, fmt.Errorf("namespace %s annotation kelm.riftonix.io/env.aggregation '%s' must be '%s', '%s' or '%s'", ns.Name, aggregation, aggregationMax, aggregationMin, aggregationLeader)
+	}
+	var ownerEmails []string
+	for _, address := range strings.Split(ns.Annotations["kelm.riftonix.io/owner.email"], ",") {
+		address = strings.TrimSpace(address)
+		if address == "" {
+			continue
+		}
+		parsedAddress, err := mail.ParseAddress(address)
+		if err != nil {
+			return rawEnvPart, fmt.Errorf("failed to parse namespace %s annotation kelm.riftonix.io/owner.email '%s': %w", ns.Name, address, err)
+		}
+		ownerEmails = append(ownerEmails, parsedAddress.Address)
 	}
-	var unmarshaledNotificationFactors []float64
-	err = json.Unmarshal([]byte(notificationFactors), &unmarshaledNotificationFactors)
+	notifyRoutes, err := parseNotifyRoutes(ns.Annotations["kelm.riftonix.io/notify"])
 	if err != nil {
-		return rawEnvPart, fmt.Errorf("failed to parse namespace %s annotation kelm.riftonix.io/ttl.notificationFactors '%s': %w", ns.Name, notificationFactors, err)
+		return rawEnvPart, fmt.Errorf("failed to parse namespace %s annotation kelm.riftonix.io/notify '%s': %w", ns.Name, ns.Annotations["kelm.riftonix.io/notify"], err)
+	}
+	notifyTimezone := ns.Annotations["kelm.riftonix.io/notify.timezone"]
+	if notifyTimezone != "" {
+		if _, err := time.LoadLocation(notifyTimezone); err != nil {
+			return rawEnvPart, fmt.Errorf("failed to parse namespace %s annotation kelm.riftonix.io/notify.timezone '%s': %w", ns.Name, notifyTimezone, err)
+		}
+	}
+	var parsedHoldSince, parsedHoldUntil time.Time
+	// hold.since is kept after the hold ends until kelm records the held time
+	if holdSince != "" {
+		parsedHoldSince, err = timer.ParseTime(holdSince)
+		if err != nil {
+			return rawEnvPart, fmt.Errorf("failed to parse namespace %s annotation kelm.riftonix.io/hold.since '%s': %w", ns.Name, holdSince, err)
+		}
+	}
+	if hold == "true" && holdUntil != "" {
+		parsedHoldUntil, err = timer.ParseTime(holdUntil)
+		if err != nil {
+			return rawEnvPart, fmt.Errorf("failed to parse namespace %s annotation kelm.riftonix.io/hold.until '%s': %w", ns.Name, holdUntil, err)
+		}
 	}
 	rawEnvPart.Name = ns.Name
 	rawEnvPart.IsManaged = true
@@ -139,6 +387,29 @@ func handleNamespace(ns core.Namespace) (RawEnvPart, error) {
 	rawEnvPart.NsData = ns
 	rawEnvPart.CreationTimestamp = ns.CreationTimestamp.Time.UTC()
 	rawEnvPart.UpdateTimestamp = parsedUpdateTimestamp
+	rawEnvPart.ExpiresAt = parsedExpiresAt.UTC()
+	rawEnvPart.Schedule = schedule
+	rawEnvPart.MaxLifetime = parsedMaxLifetime
+	rawEnvPart.Clock = clock
+	// A hold past its hold.until has lapsed and ends like a removed one
+	rawEnvPart.Hold = hold == "true" && (parsedHoldUntil.IsZero() || parsedHoldUntil.After(time.Now()))
+	rawEnvPart.HoldSince = parsedHoldSince.UTC()
+	rawEnvPart.HoldUntil = parsedHoldUntil.UTC()
+	rawEnvPart.HoldReason = ns.Annotations["kelm.riftonix.io/hold.reason"]
+	rawEnvPart.HeldFor = parseHeldFor(ns)
+	rawEnvPart.Aggregation = aggregation
+	rawEnvPart.IsLeader = ns.Annotations["kelm.riftonix.io/env.leader"] == "true"
+	rawEnvPart.NotifyChannel = ns.Annotations["kelm.riftonix.io/notify.channel"]
+	rawEnvPart.OwnerEmails = ownerEmails
+	rawEnvPart.NotifyRoutes = notifyRoutes
+	rawEnvPart.NotifyTimezone = notifyTimezone
+	rawEnvPart.Notified = parseNotifiedStatus(ns)
+	rawEnvPart.Replenished = parseReplenishedStatus(ns)
+	rawEnvPart.DeletionInterrupted = parseDeletionInterrupted(ns)
+	rawEnvPart.DeletionStarted = parseDeletionStarted(ns)
+	rawEnvPart.Emitted = parseEmittedStatus(ns)
+	rawEnvPart.RegisteredEnv = ns.Annotations["kelm.riftonix.io/status.registered"]
+	rawEnvPart.DefaultedAnnotations = defaulted
 	if isZarfEnabled() && ns.Labels["zarf.dev/agent"] == "enabled" {
 		zarfPackageName := ns.Annotations["zarf.dev/package.name"]
 		if zarfPackageName == "" {
@@ -153,17 +424,139 @@ func handleNamespace(ns core.Namespace) (RawEnvPart, error) {
 	return rawEnvPart, nil
 }
 
+// getAggregation returns the aggregation strategy of the env group.
+// Namespaces without kelm.riftonix.io/env.aggregation follow the others;
+// conflicting strategies or a leader strategy without exactly one leader fall back to max.
+func getAggregation(rawEnvParts []RawEnvPart) string {
+	var aggregation string
+	var leaders []string
+	for _, rawEnvPart := range rawEnvParts {
+		if rawEnvPart.IsLeader {
+			leaders = append(leaders, rawEnvPart.Name)
+		}
+		if rawEnvPart.Aggregation == "" || rawEnvPart.Aggregation == aggregation {
+			continue
+		}
+		if aggregation != "" {
+			logrus.Warningf("Env '%s' has conflicting kelm.riftonix.io/env.aggregation '%s' and '%s', using '%s'", rawEnvPart.EnvName, aggregation, rawEnvPart.Aggregation, aggregationMax)
+			return aggregationMax
+		}
+		aggregation = rawEnvPart.Aggregation
+	}
+	if aggregation == aggregationLeader && len(leaders) != 1 {
+		logrus.Warningf("Env '%s' must have exactly one namespace with kelm.riftonix.io/env.leader, got %v, using '%s'", rawEnvParts[0].EnvName, leaders, aggregationMax)
+		return aggregationMax
+	}
+	if aggregation == "" {
+		return aggregationMax
+	}
+	return aggregation
+}
+
+// aggregateRawEnv combines all namespaces of the env group with the group aggregation strategy
+func aggregateRawEnv(rawEnvParts []RawEnvPart) RawEnv {
+	rawEnv := RawEnv{Aggregation: getAggregation(rawEnvParts)}
+	for _, rawEnvPart := range rawEnvParts {
+		rawEnv = updateRawEnv(rawEnv, rawEnvPart)
+	}
+	return rawEnv
+}
+
 func updateRawEnv(rawEnv RawEnv, rawEnvPart RawEnvPart) RawEnv {
-	var err error
 	rawEnv.Name = rawEnvPart.EnvName
 	rawEnv.Namespaces = append(rawEnv.Namespaces, rawEnvPart.NsData)
-	if rawEnv.Ttl == "" {
-		rawEnv.Ttl = "0s" // Default value
+	switch rawEnv.Aggregation {
+	case aggregationMin:
+		rawEnv = updateRawEnvTtlMin(rawEnv, rawEnvPart, len(rawEnv.Namespaces) == 1)
+	case aggregationLeader:
+		// Only the leader defines TTL settings, the single merge works like max
+		if rawEnvPart.IsLeader {
+			rawEnv = updateRawEnvTtlMax(rawEnv, rawEnvPart)
+		}
+	default:
+		rawEnv = updateRawEnvTtlMax(rawEnv, rawEnvPart)
 	}
-	rawEnv.Ttl, err = timer.GetMaxDuration(rawEnv.Ttl, rawEnvPart.Ttl)
-	if err != nil {
-		// You should not see this log, rawEnvPart already validated
-		logrus.Warningf("Ttl in %s has bad format '%s': %v", rawEnvPart.Name, rawEnvPart.Ttl, err)
+	// Lifetime cap is a hard limit, so the earliest creation and the strictest cap win
+	if rawEnv.FirstCreationTimestamp.IsZero() {
+		rawEnv.FirstCreationTimestamp = rawEnvPart.CreationTimestamp
+	}
+	rawEnv.FirstCreationTimestamp = timer.GetMinTime(rawEnv.FirstCreationTimestamp, rawEnvPart.CreationTimestamp)
+	rawEnv.MaxLifetime = getMinLifetime(rawEnv.MaxLifetime, rawEnvPart.MaxLifetime)
+	// Business clock counts less time, so it keeps the group alive longer
+	if rawEnv.Clock != clockBusiness {
+		rawEnv.Clock = rawEnvPart.Clock
+	}
+	if rawEnvPart.Hold {
+		rawEnv = updateRawEnvHold(rawEnv, rawEnvPart)
+	} else if !rawEnvPart.HoldSince.IsZero() {
+		rawEnv = updateRawEnvReleasedHold(rawEnv, rawEnvPart)
+	}
+	rawEnv.HeldFor = max(rawEnv.HeldFor, rawEnvPart.HeldFor)
+	// Every team that owns a namespace of the env is notified
+	if rawEnvPart.NotifyChannel != "" && !slices.Contains(rawEnv.NotifyChannels, rawEnvPart.NotifyChannel) {
+		rawEnv.NotifyChannels = append(rawEnv.NotifyChannels, rawEnvPart.NotifyChannel)
+	}
+	for _, owner := range rawEnvPart.OwnerEmails {
+		if !slices.Contains(rawEnv.OwnerEmails, owner) {
+			rawEnv.OwnerEmails = append(rawEnv.OwnerEmails, owner)
+		}
+	}
+	for _, route := range rawEnvPart.NotifyRoutes {
+		if !slices.Contains(rawEnv.NotifyRoutes, route) {
+			rawEnv.NotifyRoutes = append(rawEnv.NotifyRoutes, route)
+		}
+	}
+	if rawEnvPart.NotifyTimezone != "" {
+		if rawEnv.NotifyTimezone == "" {
+			rawEnv.NotifyTimezone = rawEnvPart.NotifyTimezone
+		} else if rawEnv.NotifyTimezone != rawEnvPart.NotifyTimezone {
+			logrus.Warnf("Namespace %s notify timezone %q conflicts with %q of env '%s', keeping %q", rawEnvPart.Name, rawEnvPart.NotifyTimezone, rawEnv.NotifyTimezone, rawEnvPart.EnvName, rawEnv.NotifyTimezone)
+		}
+	}
+	if rawEnvPart.RegisteredEnv != rawEnvPart.EnvName {
+		rawEnv.UnregisteredNamespaces = append(rawEnv.UnregisteredNamespaces, rawEnvPart.Name)
+	}
+	if len(rawEnvPart.Notified.Factors) > 0 || !rawEnvPart.Notified.Warned.IsZero() {
+		rawEnv.NotifiedStatuses = append(rawEnv.NotifiedStatuses, rawEnvPart.Notified)
+	}
+	// Replenishment is kelm state of the whole group, the latest recorded anchor wins
+	if rawEnvPart.Replenished.Anchor.After(rawEnv.Replenished.Anchor) {
+		rawEnv.Replenished = rawEnvPart.Replenished
+	}
+	if rawEnv.ReplenishedStatuses == nil {
+		rawEnv.ReplenishedStatuses = make(map[string]replenishedStatus)
+	}
+	rawEnv.ReplenishedStatuses[rawEnvPart.Name] = rawEnvPart.Replenished
+	if !rawEnvPart.DeletionInterrupted.IsZero() && (rawEnv.DeletionInterrupted.IsZero() || rawEnvPart.DeletionInterrupted.Before(rawEnv.DeletionInterrupted)) {
+		rawEnv.DeletionInterrupted = rawEnvPart.DeletionInterrupted
+	}
+	rawEnv.DeletionStarted = timer.GetMaxTime(rawEnv.DeletionStarted, rawEnvPart.DeletionStarted)
+	if rawEnvPart.Emitted != nil {
+		rawEnv.Emitted = true
+		rawEnv.EmittedDeadline = timer.GetMaxTime(rawEnv.EmittedDeadline, rawEnvPart.Emitted.ExpiresAt)
+	} else {
+		rawEnv.UnemittedNamespaces = append(rawEnv.UnemittedNamespaces, rawEnvPart.Name)
+	}
+	if rawEnvPart.IsZarf {
+		rawEnv.IsZarf = true
+		rawEnv.ZarfPackageName = rawEnvPart.ZarfPackageName
+	}
+	return rawEnv
+}
+
+// updateRawEnvTtlMax merges TTL settings so the group lives as long as its longest-lived namespace
+func updateRawEnvTtlMax(rawEnv RawEnv, rawEnvPart RawEnvPart) RawEnv {
+	var err error
+	// Namespaces with only an absolute deadline do not take part in TTL merge
+	if rawEnvPart.Ttl != "" {
+		if rawEnv.Ttl == "" {
+			rawEnv.Ttl = "0s" // Default value
+		}
+		rawEnv.Ttl, err = timer.GetMaxDuration(rawEnv.Ttl, rawEnvPart.Ttl)
+		if err != nil {
+			// You should not see this log, rawEnvPart already validated
+			logrus.Warningf("Ttl in %s has bad format '%s': %v", rawEnvPart.Name, rawEnvPart.Ttl, err)
+		}
 	}
 	rawEnv.ReplenishRatio = max(rawEnv.ReplenishRatio, rawEnvPart.ReplenishRatio)
 	rawEnv.NotificationFactors = append(rawEnv.NotificationFactors, rawEnvPart.NotificationFactors...)
@@ -171,66 +564,361 @@ func updateRawEnv(rawEnv RawEnv, rawEnvPart RawEnvPart) RawEnv {
 	rawEnv.NotificationFactors = slices.Compact(rawEnv.NotificationFactors)
 	rawEnv.CreationTimestamp = timer.GetMaxTime(rawEnv.CreationTimestamp, rawEnvPart.CreationTimestamp)
 	rawEnv.UpdateTimestamp = timer.GetMaxTime(rawEnv.UpdateTimestamp, rawEnvPart.UpdateTimestamp)
-	if rawEnvPart.IsZarf {
-		rawEnv.IsZarf = true
-		rawEnv.ZarfPackageName = rawEnvPart.ZarfPackageName
+	// The latest deadline wins, like the maximum TTL does
+	rawEnv.ExpiresAt = timer.GetMaxTime(rawEnv.ExpiresAt, rawEnvPart.ExpiresAt)
+	if rawEnvPart.Schedule != "" && !slices.Contains(rawEnv.Schedules, rawEnvPart.Schedule) {
+		rawEnv.Schedules = append(rawEnv.Schedules, rawEnvPart.Schedule)
 	}
 	return rawEnv
 }
 
-func getEnvs(client kubernetes.Interface, labelsSet labels.Set) (map[string]Env, error) {
-	filter := meta.ListOptions{
-		LabelSelector: labels.SelectorFromSet(labelsSet).String(),
+// updateRawEnvTtlMin merges TTL settings so the group expires with its shortest-lived namespace.
+// Only notification factors requested by every namespace are kept.
+func updateRawEnvTtlMin(rawEnv RawEnv, rawEnvPart RawEnvPart, first bool) RawEnv {
+	if rawEnvPart.Ttl != "" {
+		if rawEnv.Ttl == "" {
+			rawEnv.Ttl = rawEnvPart.Ttl
+		}
+		var err error
+		rawEnv.Ttl, err = timer.GetMinDuration(rawEnv.Ttl, rawEnvPart.Ttl)
+		if err != nil {
+			// You should not see this log, rawEnvPart already validated
+			logrus.Warningf("Ttl in %s has bad format '%s': %v", rawEnvPart.Name, rawEnvPart.Ttl, err)
+		}
+	}
+	if first {
+		rawEnv.ReplenishRatio = rawEnvPart.ReplenishRatio
+		rawEnv.NotificationFactors = slices.Clone(rawEnvPart.NotificationFactors)
+		slices.Sort(rawEnv.NotificationFactors)
+		rawEnv.NotificationFactors = slices.Compact(rawEnv.NotificationFactors)
+		rawEnv.CreationTimestamp = rawEnvPart.CreationTimestamp
+		rawEnv.UpdateTimestamp = rawEnvPart.UpdateTimestamp
+	} else {
+		rawEnv.ReplenishRatio = min(rawEnv.ReplenishRatio, rawEnvPart.ReplenishRatio)
+		rawEnv.NotificationFactors = slices.DeleteFunc(rawEnv.NotificationFactors, func(factor float64) bool {
+			return !slices.Contains(rawEnvPart.NotificationFactors, factor)
+		})
+		rawEnv.CreationTimestamp = timer.GetMinTime(rawEnv.CreationTimestamp, rawEnvPart.CreationTimestamp)
+		rawEnv.UpdateTimestamp = timer.GetMinTime(rawEnv.UpdateTimestamp, rawEnvPart.UpdateTimestamp)
+	}
+	if rawEnv.ExpiresAt.IsZero() {
+		rawEnv.ExpiresAt = rawEnvPart.ExpiresAt
+	} else if !rawEnvPart.ExpiresAt.IsZero() {
+		rawEnv.ExpiresAt = timer.GetMinTime(rawEnv.ExpiresAt, rawEnvPart.ExpiresAt)
+	}
+	if rawEnvPart.Schedule != "" && !slices.Contains(rawEnv.Schedules, rawEnvPart.Schedule) {
+		rawEnv.Schedules = append(rawEnv.Schedules, rawEnvPart.Schedule)
+	}
+	return rawEnv
+}
+
+// getMinLifetime returns the stricter of two lifetime caps, 0 means no cap
+func getMinLifetime(a, b time.Duration) time.Duration {
+	if a == 0 {
+		return b
+	}
+	if b == 0 {
+		return a
 	}
+	return min(a, b)
+}
+
+// updateRawEnvHold merges a namespace hold into the env group.
+// One held namespace holds the whole group: the earliest start and the latest end win,
+// and a hold without hold.until keeps the group on hold indefinitely.
+func updateRawEnvHold(rawEnv RawEnv, rawEnvPart RawEnvPart) RawEnv {
+	if !rawEnv.Hold {
+		rawEnv.HoldUntil = rawEnvPart.HoldUntil
+	} else if rawEnv.HoldUntil.IsZero() || rawEnvPart.HoldUntil.IsZero() {
+		rawEnv.HoldUntil = time.Time{}
+	} else {
+		rawEnv.HoldUntil = timer.GetMaxTime(rawEnv.HoldUntil, rawEnvPart.HoldUntil)
+	}
+	rawEnv.Hold = true
+	if rawEnvPart.HoldSince.IsZero() {
+		rawEnv.UnrecordedHoldNamespaces = append(rawEnv.UnrecordedHoldNamespaces, rawEnvPart.Name)
+	} else if rawEnv.HoldSince.IsZero() {
+		rawEnv.HoldSince = rawEnvPart.HoldSince
+	} else {
+		rawEnv.HoldSince = timer.GetMinTime(rawEnv.HoldSince, rawEnvPart.HoldSince)
+	}
+	if rawEnvPart.HoldReason != "" && !slices.Contains(rawEnv.HoldReasons, rawEnvPart.HoldReason) {
+		rawEnv.HoldReasons = append(rawEnv.HoldReasons, rawEnvPart.HoldReason)
+	}
+	return rawEnv
+}
+
+// updateRawEnvReleasedHold merges a namespace hold that ended but still has its start recorded.
+// A lapsed hold ends at hold.until, a removed one when kelm sees it gone.
+func updateRawEnvReleasedHold(rawEnv RawEnv, rawEnvPart RawEnvPart) RawEnv {
+	end := rawEnvPart.HoldUntil
+	if end.IsZero() {
+		end = time.Now().UTC()
+	}
+	if rawEnv.ReleasedHoldSince.IsZero() {
+		rawEnv.ReleasedHoldSince = rawEnvPart.HoldSince
+	}
+	rawEnv.ReleasedHoldSince = timer.GetMinTime(rawEnv.ReleasedHoldSince, rawEnvPart.HoldSince)
+	rawEnv.ReleasedHoldEnd = timer.GetMaxTime(rawEnv.ReleasedHoldEnd, end)
+	rawEnv.ReleasedHoldNamespaces = append(rawEnv.ReleasedHoldNamespaces, rawEnvPart.Name)
+	return rawEnv
+}
+
+// parseHeldFor returns kelm.riftonix.io/status.held, the time earlier holds froze the countdown
+func parseHeldFor(ns core.Namespace) time.Duration {
+	s := ns.Annotations["kelm.riftonix.io/status.held"]
+	if s == "" {
+		return 0
+	}
+	// Status is written by kelm, a broken one only means earlier holds are not counted
+	heldFor, err := timer.ParseDuration(s)
+	if err != nil || heldFor < 0 {
+		logrus.Warnf("Ignoring namespace %s annotation kelm.riftonix.io/status.held '%s': %v", ns.Name, s, err)
+		return 0
+	}
+	return heldFor
+}
+
+// getEnvs builds envs from the cached namespaces matching labelsSet
+func getEnvs(lister listers.NamespaceLister, labelsSet labels.Set) (map[string]Env, error) {
 	logrus.Debug("Gathering namespaces...")
-	namespaces, err := client.CoreV1().Namespaces().List(context.Background(), filter)
+	namespaces, err := lister.List(labels.SelectorFromSet(labelsSet))
 	if err != nil {
 		return nil, err
 	}
+	// The cache is unordered, sort like the API server does so merges are stable
+	slices.SortFunc(namespaces, func(a, b *core.Namespace) int {
+		return strings.Compare(a.Name, b.Name)
+	})
 	envs := make(map[string]Env)
-	rawEnvs := make(map[string]RawEnv)
-	for _, ns := range namespaces.Items {
-		rawEnvPart, err := handleNamespace(ns)
+	rawEnvParts := make(map[string][]RawEnvPart)
+	for _, ns := range namespaces {
+		rawEnvPart, err := handleNamespace(*ns)
 		if err != nil {
 			logrus.Warningf("%v", err)
+			recordNamespaceInvalid(*ns, err)
 			continue
 		}
-		rawEnvs[rawEnvPart.EnvName] = updateRawEnv(rawEnvs[rawEnvPart.EnvName], rawEnvPart)
-	}
-	for _, rawEnv := range rawEnvs {
-		var env Env
-		env.Name = rawEnv.Name
-		for _, ns := range rawEnv.Namespaces {
-			env.Namespaces = append(env.Namespaces, ns.Name)
+		// A recorded registration survives restarts, so it is not reported again
+		if rawEnvPart.RegisteredEnv != rawEnvPart.EnvName {
+			recordNamespaceRegistered(rawEnvPart)
 		}
-		env.RemainingTtl, err = timer.GetDuration(rawEnv.CreationTimestamp, rawEnv.Ttl, 1)
+		if len(rawEnvPart.DefaultedAnnotations) > 0 {
+			logrus.Debugf("Namespace %s uses operator defaults for %v", rawEnvPart.Name, rawEnvPart.DefaultedAnnotations)
+		}
+		rawEnvParts[rawEnvPart.EnvName] = append(rawEnvParts[rawEnvPart.EnvName], rawEnvPart)
+	}
+	for _, parts := range rawEnvParts {
+		rawEnv := aggregateRawEnv(parts)
+		env, err := buildEnv(rawEnv)
 		if err != nil {
 			// You should not see this log, rawEnvPart already validated
-			logrus.Warningf("Failed to parse annotations in %s: %v\n", rawEnv.Name, err)
+			logrus.Warningf("Failed to parse annotations in %s: %v", rawEnv.Name, err)
 			continue
 		}
-		env.ReplenishRatio = rawEnv.ReplenishRatio
-		env.IsZarf = rawEnv.IsZarf
-		env.ZarfPackageName = rawEnv.ZarfPackageName
-		for _, factor := range rawEnv.NotificationFactors {
-			remainingNotificationTtl, err := timer.GetDuration(rawEnv.CreationTimestamp, rawEnv.Ttl, factor)
-			if err != nil {
-				logrus.Warningf("Failed to parse annotations in %s: %v", rawEnv.Name, err)
-				continue
-			}
-			env.RemainingNotificationsTtl = append(env.RemainingNotificationsTtl, remainingNotificationTtl)
+		var remainingNotificationsTtl []string
+		for _, remaining := range env.RemainingNotificationsTtl {
+			remainingNotificationsTtl = append(remainingNotificationsTtl, timer.FormatDuration(remaining))
 		}
-		env.CreationTimestamp = rawEnv.CreationTimestamp
-		env.UpdateTimestamp = rawEnv.UpdateTimestamp
 		logrus.WithFields(logrus.Fields{
 			"Namespaces":                env.Namespaces,
-			"RemainingTtl":              env.RemainingTtl,
+			"Aggregation":               env.Aggregation,
+			"RemainingTtl":              timer.FormatDuration(env.RemainingTtl),
 			"ReplenishRatio":            env.ReplenishRatio,
-			"RemainingNotificationsTtl": env.RemainingNotificationsTtl,
+			"RemainingNotificationsTtl": remainingNotificationsTtl,
 			"CreationTimestamp":         env.CreationTimestamp,
 			"UpdateTimestamp":           env.UpdateTimestamp,
+			"AnchorTimestamp":           env.AnchorTimestamp,
+			"ExpiresAt":                 env.ExpiresAt,
+			"MaxLifetime":               timer.FormatDuration(env.MaxLifetime),
+			"Clock":                     env.Clock,
+			"Hold":                      env.Hold,
+			"HoldUntil":                 env.HoldUntil,
+			"HoldReason":                env.HoldReason,
 		}).Infof("Env '%s' updated", env.Name)
 		envs[rawEnv.Name] = env
 	}
 	return envs, nil
 }
+
+// getScheduledDeadline returns the first fire after the anchor across group schedules,
+// the latest one unless the group aggregates with min; zero time if the group has no schedule.
+func getScheduledDeadline(schedules []string, anchor time.Time, aggregation string) (time.Time, error) {
+	var deadline time.Time
+	for _, spec := range schedules {
+		schedule, err := timer.ParseSchedule(spec)
+		if err != nil {
+			return deadline, err
+		}
+		next := schedule.Next(anchor)
+		if aggregation == aggregationMin && !deadline.IsZero() {
+			deadline = timer.GetMinTime(deadline, next)
+			continue
+		}
+		deadline = timer.GetMaxTime(deadline, next)
+	}
+	return deadline, nil
+}
+
+// getExpiresAt returns the env deadline.
+// TTL counts from the anchor; an absolute expiresAt or the next scheduled removal caps it,
+// and the earliest of them wins.
+// Ended holds and the current one move the deadline, and a zero deadline means the env is held indefinitely.
+// The lifetime cap is applied on top, no matter how often the env was extended or held.
+func getExpiresAt(rawEnv RawEnv, clock timer.Clock, anchor time.Time, maxLifetime time.Duration) (time.Time, error) {
+	deadline := rawEnv.ExpiresAt
+	scheduledDeadline, err := getScheduledDeadline(rawEnv.Schedules, anchor, rawEnv.Aggregation)
+	if err != nil {
+		return deadline, err
+	}
+	if !scheduledDeadline.IsZero() {
+		if deadline.IsZero() {
+			deadline = scheduledDeadline
+		}
+		deadline = timer.GetMinTime(deadline, scheduledDeadline)
+	}
+	if rawEnv.Ttl != "" {
+		ttlDeadline, err := timer.GetDeadline(clock, anchor, rawEnv.Ttl, 1)
+		if err != nil {
+			return ttlDeadline, err
+		}
+		if deadline.IsZero() {
+			deadline = ttlDeadline
+		}
+		deadline = timer.GetMinTime(deadline, ttlDeadline)
+	}
+	if rawEnv.HeldFor > 0 && !deadline.IsZero() {
+		deadline = clock.Add(deadline, rawEnv.HeldFor)
+	}
+	if rawEnv.Hold {
+		deadline = applyHold(clock, deadline, rawEnv.HoldSince, rawEnv.HoldUntil)
+	}
+	if maxLifetime > 0 {
+		lifetimeDeadline := rawEnv.FirstCreationTimestamp.Add(maxLifetime)
+		if deadline.IsZero() {
+			deadline = lifetimeDeadline
+		}
+		deadline = timer.GetMinTime(deadline, lifetimeDeadline)
+	}
+	return deadline, nil
+}
+
+// applyHold freezes the countdown between since and until:
+// the TTL left when the hold started resumes when the hold lapses.
+// A zero until is an indefinite hold and returns a zero deadline.
+func applyHold(clock timer.Clock, deadline time.Time, since time.Time, until time.Time) time.Time {
+	if !since.Before(deadline) {
+		// Hold started after expiry, nothing left to freeze
+		return deadline
+	}
+	if until.IsZero() {
+		return time.Time{}
+	}
+	if !until.After(since) {
+		return deadline
+	}
+	return clock.Add(until, clock.Between(since, deadline))
+}
+
+// releaseHold returns HeldFor with the hold that has just ended added.
+// A hold that started after the deadline froze nothing.
+func releaseHold(rawEnv RawEnv, clock timer.Clock, anchor time.Time) time.Duration {
+	deadline, err := getExpiresAt(rawEnv, clock, anchor, 0)
+	if err != nil || !rawEnv.ReleasedHoldSince.Before(deadline) || !rawEnv.ReleasedHoldEnd.After(rawEnv.ReleasedHoldSince) {
+		return rawEnv.HeldFor
+	}
+	return rawEnv.HeldFor + clock.Between(rawEnv.ReleasedHoldSince, rawEnv.ReleasedHoldEnd).Truncate(time.Second)
+}
+
+func buildEnv(rawEnv RawEnv) (Env, error) {
+	var env Env
+	var err error
+	env.Name = rawEnv.Name
+	env.Aggregation = rawEnv.Aggregation
+	env.NamespaceUIDs = make(map[string]types.UID, len(rawEnv.Namespaces))
+	for _, ns := range rawEnv.Namespaces {
+		env.Namespaces = append(env.Namespaces, ns.Name)
+		env.NamespaceUIDs[ns.Name] = ns.UID
+	}
+	if rawEnv.Clock == "" {
+		rawEnv.Clock = clockWall
+	}
+	clock := getTtlClock(rawEnv.Clock)
+	env.Clock = rawEnv.Clock
+	env.AnchorTimestamp, env.Replenished = getTtlAnchor(rawEnv, clock)
+	if !env.Replenished.Anchor.IsZero() {
+		for _, ns := range env.Namespaces {
+			if rawEnv.ReplenishedStatuses[ns] != env.Replenished {
+				env.UnrecordedReplenishedNamespaces = append(env.UnrecordedReplenishedNamespaces, ns)
+			}
+		}
+	}
+	if !rawEnv.ReleasedHoldSince.IsZero() {
+		if rawEnv.Hold {
+			// Another namespace still holds the group, so the freeze goes on from the earlier start
+			if rawEnv.HoldSince.IsZero() {
+				rawEnv.HoldSince = rawEnv.ReleasedHoldSince
+			}
+			rawEnv.HoldSince = timer.GetMinTime(rawEnv.HoldSince, rawEnv.ReleasedHoldSince)
+		} else {
+			// The remaining TTL resumes from the end of the hold, not from the original deadline
+			rawEnv.HeldFor = releaseHold(rawEnv, clock, env.AnchorTimestamp)
+			env.ReleasedHoldNamespaces = rawEnv.ReleasedHoldNamespaces
+		}
+	}
+	if rawEnv.Hold && rawEnv.HoldSince.IsZero() {
+		// Hold has just been set, it starts now and kelm records it on the namespaces
+		rawEnv.HoldSince = time.Now().UTC()
+	}
+	env.Hold = rawEnv.Hold
+	env.HoldSince = rawEnv.HoldSince
+	env.HoldUntil = rawEnv.HoldUntil
+	env.HoldReason = strings.Join(rawEnv.HoldReasons, "; ")
+	env.UnrecordedHoldNamespaces = rawEnv.UnrecordedHoldNamespaces
+	env.HeldFor = rawEnv.HeldFor
+	env.NotifyChannels = rawEnv.NotifyChannels
+	env.OwnerEmails = rawEnv.OwnerEmails
+	env.NotifyRoutes = rawEnv.NotifyRoutes
+	env.NotifyTimezone = rawEnv.NotifyTimezone
+	env.MaxLifetime = getMinLifetime(rawEnv.MaxLifetime, getMaxLifetime())
+	env.ExpiresAt, err = getExpiresAt(rawEnv, clock, env.AnchorTimestamp, env.MaxLifetime)
+	if err != nil {
+		return env, err
+	}
+	env.RemainingTtl = timer.GetRemaining(env.ExpiresAt)
+	env.ReplenishRatio = rawEnv.ReplenishRatio
+	env.IsZarf = rawEnv.IsZarf
+	env.ZarfPackageName = rawEnv.ZarfPackageName
+	// Notification offsets are fractions of the lifetime between anchor and deadline
+	for _, factor := range rawEnv.NotificationFactors {
+		if env.ExpiresAt.IsZero() {
+			break
+		}
+		notificationTime := timer.GetFactorTime(clock, env.AnchorTimestamp, env.ExpiresAt, factor)
+		env.NotificationFactors = append(env.NotificationFactors, factor)
+		env.NotificationTimestamps = append(env.NotificationTimestamps, notificationTime)
+		env.RemainingNotificationsTtl = append(env.RemainingNotificationsTtl, timer.GetRemaining(notificationTime))
+	}
+	// Markers of an older anchor belong to a lifetime before the last extension,
+	// its warning still counts until an extended notification resolves it
+	for _, status := range rawEnv.NotifiedStatuses {
+		if status.Anchor.Equal(env.AnchorTimestamp) {
+			env.NotifiedFactors = mergeFactors(env.NotifiedFactors, status.Factors)
+		}
+		env.WarnedDeadline = timer.GetMaxTime(env.WarnedDeadline, status.Warned)
+	}
+	env.CreationTimestamp = rawEnv.CreationTimestamp
+	env.UpdateTimestamp = rawEnv.UpdateTimestamp
+	env.DeletionInterrupted = rawEnv.DeletionInterrupted
+	env.DeletionStarted = rawEnv.DeletionStarted
+	env.Emitted = rawEnv.Emitted
+	env.EmittedDeadline = rawEnv.EmittedDeadline
+	env.UnemittedNamespaces = rawEnv.UnemittedNamespaces
+	env.UnregisteredNamespaces = rawEnv.UnregisteredNamespaces
+	if !env.DeletionInterrupted.IsZero() {
+		// Part of the env may already be gone, so a later hold or extension does not save it
+		env.Hold = false
+		env.RemainingTtl = 0
+	}
+	return env, nil
+}
@@ -2,16 +2,19 @@ package kelm
 
 import (
 	"encoding/json"
-	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
+	"kelm/internal/pkg/timer"
+
 	core "k8s.io/api/core/v1"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
-	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/client-go/kubernetes/fake"
-	k8stesting "k8s.io/client-go/testing"
+	listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
 )
 
 func TestHandleNamespace(t *testing.T) {
@@ -75,6 +78,249 @@ func TestHandleNamespace(t *testing.T) {
 		}
 	})
 
+	t.Run("optional annotations use operator defaults", func(t *testing.T) {
+		t.Setenv("DEFAULT_REPLENISH_RATIO", "0.75")
+		t.Setenv("DEFAULT_NOTIFICATION_FACTORS", "[0.9]")
+		creation := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+		ns := *makeNamespace("defaults-ns", "env1", "1h", "", "", "", creation, "true")
+		namespace, err := handleNamespace(ns)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if namespace.ReplenishRatio != 0.75 || len(namespace.NotificationFactors) != 1 || namespace.NotificationFactors[0] != 0.9 {
+			t.Errorf("Unexpected defaulted RawEnvPart: %+v", namespace)
+		}
+		if !namespace.UpdateTimestamp.Equal(creation) {
+			t.Errorf("Expected UpdateTimestamp to default to creation %v, got %v", creation, namespace.UpdateTimestamp)
+		}
+		expected := []string{
+			"kelm.riftonix.io/ttl.replenishRatio",
+			"kelm.riftonix.io/ttl.notificationFactors",
+			"kelm.riftonix.io/updateTimestamp",
+		}
+		if !slices.Equal(namespace.DefaultedAnnotations, expected) {
+			t.Errorf("Expected DefaultedAnnotations %v, got %v", expected, namespace.DefaultedAnnotations)
+		}
+	})
+
+	t.Run("missing ttl uses DEFAULT_TTL", func(t *testing.T) {
+		t.Setenv("DEFAULT_TTL", "2d")
+		ns := *makeNamespace("default-ttl-ns", "env1", "", "1.5", string(notificationFactors), validTime, time.Now(), "true")
+		namespace, err := handleNamespace(ns)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if namespace.Ttl != "2d" || !slices.Equal(namespace.DefaultedAnnotations, []string{"kelm.riftonix.io/ttl.removal"}) {
+			t.Errorf("Unexpected defaulted RawEnvPart: %+v", namespace)
+		}
+	})
+
+	t.Run("DEFAULT_TTL does not override expiresAt", func(t *testing.T) {
+		t.Setenv("DEFAULT_TTL", "2d")
+		ns := *makeNamespace("deadline-ns", "env1", "", "1.5", string(notificationFactors), validTime, time.Now(), "true")
+		ns.Annotations["kelm.riftonix.io/expiresAt"] = "2026-10-16T18:00:00Z"
+		namespace, err := handleNamespace(ns)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if namespace.Ttl != "" || len(namespace.DefaultedAnnotations) != 0 {
+			t.Errorf("Expected deadline-only RawEnvPart, got %+v", namespace)
+		}
+	})
+
+	t.Run("expiresAt without ttl", func(t *testing.T) {
+		ns := *makeNamespace("deadline-ns", "env1", "", "1.5", string(notificationFactors), validTime, time.Now(), "true")
+		ns.Annotations["kelm.riftonix.io/expiresAt"] = "2026-10-16T18:00:00+02:00"
+		namespace, err := handleNamespace(ns)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		expected := time.Date(2026, 10, 16, 16, 0, 0, 0, time.UTC)
+		if !namespace.ExpiresAt.Equal(expected) || namespace.Ttl != "" {
+			t.Errorf("Unexpected RawEnvPart: %+v", namespace)
+		}
+	})
+
+	t.Run("bad expiresAt", func(t *testing.T) {
+		ns := *makeNamespace("deadline-ns", "env1", "1h", "1.5", string(notificationFactors), validTime, time.Now(), "true")
+		ns.Annotations["kelm.riftonix.io/expiresAt"] = "friday"
+		_, err := handleNamespace(ns)
+		if err == nil {
+			t.Error("Expected error for bad expiresAt")
+		}
+	})
+
+	t.Run("maxLifetime", func(t *testing.T) {
+		ns := *makeNamespace("capped-ns", "env1", "1h", "1.5", string(notificationFactors), validTime, time.Now(), "true")
+		ns.Annotations["kelm.riftonix.io/ttl.maxLifetime"] = "168h"
+		namespace, err := handleNamespace(ns)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if namespace.MaxLifetime != 168*time.Hour {
+			t.Errorf("Expected MaxLifetime 168h, got %v", namespace.MaxLifetime)
+		}
+	})
+
+	t.Run("bad maxLifetime", func(t *testing.T) {
+		for _, maxLifetime := range []string{"week", "-1h", "0s"} {
+			ns := *makeNamespace("capped-ns", "env1", "1h", "1.5", string(notificationFactors), validTime, time.Now(), "true")
+			ns.Annotations["kelm.riftonix.io/ttl.maxLifetime"] = maxLifetime
+			if _, err := handleNamespace(ns); err == nil {
+				t.Errorf("Expected error for maxLifetime %q", maxLifetime)
+			}
+		}
+	})
+
+	t.Run("hold", func(t *testing.T) {
+		ns := *makeNamespace("held-ns", "env1", "1h", "1.5", string(notificationFactors), validTime, time.Now(), "true")
+		ns.Annotations["kelm.riftonix.io/hold"] = "true"
+		until := time.Now().Add(72 * time.Hour).UTC().Truncate(time.Second)
+		ns.Annotations["kelm.riftonix.io/hold.until"] = until.Format(time.RFC3339)
+		ns.Annotations["kelm.riftonix.io/hold.reason"] = "release freeze"
+		namespace, err := handleNamespace(ns)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !namespace.Hold || !namespace.HoldSince.IsZero() || namespace.HoldReason != "release freeze" {
+			t.Errorf("Unexpected hold in RawEnvPart: %+v", namespace)
+		}
+		if !namespace.HoldUntil.Equal(until) {
+			t.Errorf("Unexpected HoldUntil: %v", namespace.HoldUntil)
+		}
+	})
+
+	t.Run("lapsed hold", func(t *testing.T) {
+		ns := *makeNamespace("held-ns", "env1", "1h", "1.5", string(notificationFactors), validTime, time.Now(), "true")
+		ns.Annotations["kelm.riftonix.io/hold"] = "true"
+		ns.Annotations["kelm.riftonix.io/hold.since"] = time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339)
+		ns.Annotations["kelm.riftonix.io/hold.until"] = time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+		ns.Annotations["kelm.riftonix.io/status.held"] = "30m"
+		namespace, err := handleNamespace(ns)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if namespace.Hold || namespace.HoldSince.IsZero() || namespace.HoldUntil.IsZero() || namespace.HeldFor != 30*time.Minute {
+			t.Errorf("Expected lapsed hold with its start kept, got %+v", namespace)
+		}
+	})
+
+	t.Run("bad hold.until", func(t *testing.T) {
+		ns := *makeNamespace("held-ns", "env1", "1h", "1.5", string(notificationFactors), validTime, time.Now(), "true")
+		ns.Annotations["kelm.riftonix.io/hold"] = "true"
+		ns.Annotations["kelm.riftonix.io/hold.until"] = "monday"
+		if _, err := handleNamespace(ns); err == nil {
+			t.Error("Expected error for bad hold.until")
+		}
+	})
+
+	t.Run("schedule without ttl", func(t *testing.T) {
+		ns := *makeNamespace("scheduled-ns", "env1", "", "1.5", string(notificationFactors), validTime, time.Now(), "true")
+		ns.Annotations["kelm.riftonix.io/ttl.schedule"] = "0 20 * * FRI"
+		namespace, err := handleNamespace(ns)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if namespace.Schedule != "0 20 * * FRI" {
+			t.Errorf("Unexpected Schedule: %q", namespace.Schedule)
+		}
+	})
+
+	t.Run("bad schedule", func(t *testing.T) {
+		for _, schedule := range []string{"every friday", "0 0 30 2 *"} {
+			ns := *makeNamespace("scheduled-ns", "env1", "1h", "1.5", string(notificationFactors), validTime, time.Now(), "true")
+			ns.Annotations["kelm.riftonix.io/ttl.schedule"] = schedule
+			if _, err := handleNamespace(ns); err == nil {
+				t.Errorf("Expected error for schedule %q", schedule)
+			}
+		}
+	})
+
+	t.Run("bad ttl.clock", func(t *testing.T) {
+		ns := *makeNamespace("clock-ns", "env1", "1h", "1.5", string(notificationFactors), validTime, time.Now(), "true")
+		ns.Annotations["kelm.riftonix.io/ttl.clock"] = "lunar"
+		if _, err := handleNamespace(ns); err == nil {
+			t.Error("Expected error for bad ttl.clock")
+		}
+	})
+
+	t.Run("bad env.aggregation", func(t *testing.T) {
+		ns := *makeNamespace("bad-aggregation-ns", "env1", "1h", "1.5", string(notificationFactors), validTime, time.Now(), "true")
+		ns.Annotations["kelm.riftonix.io/env.aggregation"] = "average"
+		if _, err := handleNamespace(ns); err == nil {
+			t.Error("Expected error for bad env.aggregation")
+		}
+	})
+
+	t.Run("env leader", func(t *testing.T) {
+		ns := *makeNamespace("leader-ns", "env1", "1h", "1.5", string(notificationFactors), validTime, time.Now(), "true")
+		ns.Annotations["kelm.riftonix.io/env.aggregation"] = "leader"
+		ns.Annotations["kelm.riftonix.io/env.leader"] = "true"
+		part, err := handleNamespace(ns)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if part.Aggregation != aggregationLeader || !part.IsLeader {
+			t.Errorf("Expected leader part, got %+v", part)
+		}
+	})
+
+	t.Run("owner emails", func(t *testing.T) {
+		ns := *makeNamespace("owner-ns", "env1", "1h", "1.5", string(notificationFactors), validTime, time.Now(), "true")
+		ns.Annotations["kelm.riftonix.io/owner.email"] = "Alice <alice@example.com>, bob@example.com"
+		part, err := handleNamespace(ns)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !slices.Equal(part.OwnerEmails, []string{"alice@example.com", "bob@example.com"}) {
+			t.Errorf("Unexpected OwnerEmails: %v", part.OwnerEmails)
+		}
+	})
+
+	t.Run("bad owner email", func(t *testing.T) {
+		ns := *makeNamespace("owner-ns", "env1", "1h", "1.5", string(notificationFactors), validTime, time.Now(), "true")
+		ns.Annotations["kelm.riftonix.io/owner.email"] = "alice"
+		if _, err := handleNamespace(ns); err == nil {
+			t.Error("Expected error for bad owner.email")
+		}
+	})
+
+	t.Run("notify routes", func(t *testing.T) {
+		ns := *makeNamespace("route-ns", "env1", "1h", "1.5", string(notificationFactors), validTime, time.Now(), "true")
+		ns.Annotations["kelm.riftonix.io/notify"] = "slack:#team-a,email:owner"
+		ns.Annotations["kelm.riftonix.io/notify.timezone"] = "America/New_York"
+		part, err := handleNamespace(ns)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !slices.Equal(part.NotifyRoutes, []string{"chat:#team-a", "email:owner"}) || part.NotifyTimezone != "America/New_York" {
+			t.Errorf("Unexpected routes %v and timezone %q", part.NotifyRoutes, part.NotifyTimezone)
+		}
+		ns.Annotations["kelm.riftonix.io/notify"] = "pager:team-a"
+		if _, err := handleNamespace(ns); err == nil {
+			t.Error("Expected error for unknown sink")
+		}
+		ns.Annotations["kelm.riftonix.io/notify"] = ""
+		ns.Annotations["kelm.riftonix.io/notify.timezone"] = "Mars/Olympus"
+		if _, err := handleNamespace(ns); err == nil {
+			t.Error("Expected error for bad timezone")
+		}
+	})
+
+	t.Run("ttl in days", func(t *testing.T) {
+		ns := *makeNamespace("days-ns", "env1", "7d", "1.5", string(notificationFactors), validTime, time.Now(), "true")
+		if _, err := handleNamespace(ns); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	})
+
+	t.Run("bad ttl", func(t *testing.T) {
+		ns := *makeNamespace("bad-ttl-ns", "env1", "7 days", "1.5", string(notificationFactors), validTime, time.Now(), "true")
+		if _, err := handleNamespace(ns); err == nil {
+			t.Error("Expected error for bad ttl.removal")
+		}
+	})
+
 	t.Run("bad replenishRatio", func(t *testing.T) {
 		ns := baseNamespace
 		ns.Annotations["kelm.riftonix.io/ttl.replenishRatio"] = "bad"
@@ -126,7 +372,6 @@ func TestHandleNamespace(t *testing.T) {
 		}
 	})
 
-
 	t.Run("non-zarf namespace has IsZarf=false", func(t *testing.T) {
 		ns := makeNamespace("plain-ns", "env1", "1h", "1.5", string(notificationFactors), validTime, time.Now().Add(-2*time.Hour), "true")
 		result, err := handleNamespace(*ns)
@@ -228,6 +473,387 @@ func TestUpdateRawEnv(t *testing.T) {
 	})
 }
 
+func TestUpdateRawEnvExpiresAt(t *testing.T) {
+	deadline := time.Now().Add(time.Hour).UTC()
+	ttlPart := RawEnvPart{Name: "ns1", EnvName: "env1", Ttl: "2h"}
+	deadlinePart := RawEnvPart{Name: "ns2", EnvName: "env1", ExpiresAt: deadline}
+	laterDeadlinePart := RawEnvPart{Name: "ns3", EnvName: "env1", ExpiresAt: deadline.Add(time.Hour)}
+
+	t.Run("deadline-only part keeps ttl unset", func(t *testing.T) {
+		rawEnv := updateRawEnv(RawEnv{}, deadlinePart)
+		if rawEnv.Ttl != "" {
+			t.Errorf("Expected empty Ttl, got %q", rawEnv.Ttl)
+		}
+		if !rawEnv.ExpiresAt.Equal(deadline) {
+			t.Errorf("Expected ExpiresAt %v, got %v", deadline, rawEnv.ExpiresAt)
+		}
+	})
+
+	t.Run("ttl and deadlines are merged", func(t *testing.T) {
+		rawEnv := updateRawEnv(updateRawEnv(updateRawEnv(RawEnv{}, deadlinePart), ttlPart), laterDeadlinePart)
+		if rawEnv.Ttl != "2h" {
+			t.Errorf("Expected Ttl '2h', got %q", rawEnv.Ttl)
+		}
+		if !rawEnv.ExpiresAt.Equal(deadline.Add(time.Hour)) {
+			t.Errorf("Expected latest ExpiresAt %v, got %v", deadline.Add(time.Hour), rawEnv.ExpiresAt)
+		}
+	})
+}
+
+func TestAggregateRawEnv(t *testing.T) {
+	creation := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	deadline := creation.Add(48 * time.Hour)
+	short := RawEnvPart{
+		Name: "ns1", EnvName: "env1", Ttl: "1h", ReplenishRatio: 0.5, NotificationFactors: []float64{0.5, 0.8},
+		CreationTimestamp: creation, UpdateTimestamp: creation.Add(time.Hour), ExpiresAt: deadline,
+	}
+	long := RawEnvPart{
+		Name: "ns2", EnvName: "env1", Ttl: "1d", ReplenishRatio: 1.5, NotificationFactors: []float64{0.8, 0.9},
+		CreationTimestamp: creation.Add(time.Hour), UpdateTimestamp: creation.Add(2 * time.Hour), ExpiresAt: deadline.Add(time.Hour),
+	}
+	deadlineOnly := RawEnvPart{Name: "ns3", EnvName: "env1", NotificationFactors: []float64{0.8}, CreationTimestamp: creation, ExpiresAt: deadline.Add(-time.Hour)}
+	with := func(part RawEnvPart, aggregation string, leader bool) RawEnvPart {
+		part.Aggregation = aggregation
+		part.IsLeader = leader
+		return part
+	}
+
+	tests := []struct {
+		name                string
+		parts               []RawEnvPart
+		expectedAggregation string
+		expectedTtl         string
+		expectedRatio       float64
+		expectedFactors     []float64
+		expectedCreation    time.Time
+		expectedUpdate      time.Time
+		expectedExpiresAt   time.Time
+	}{
+		{
+			name:                "default is max",
+			parts:               []RawEnvPart{short, long},
+			expectedAggregation: aggregationMax,
+			expectedTtl:         "1d",
+			expectedRatio:       1.5,
+			expectedFactors:     []float64{0.5, 0.8, 0.9},
+			expectedCreation:    creation.Add(time.Hour),
+			expectedUpdate:      creation.Add(2 * time.Hour),
+			expectedExpiresAt:   deadline.Add(time.Hour),
+		},
+		{
+			name:                "min",
+			parts:               []RawEnvPart{with(short, aggregationMin, false), long},
+			expectedAggregation: aggregationMin,
+			expectedTtl:         "1h",
+			expectedRatio:       0.5,
+			expectedFactors:     []float64{0.8},
+			expectedCreation:    creation,
+			expectedUpdate:      creation.Add(time.Hour),
+			expectedExpiresAt:   deadline,
+		},
+		{
+			name:                "min ignores unset ttl",
+			parts:               []RawEnvPart{with(deadlineOnly, aggregationMin, false), long},
+			expectedAggregation: aggregationMin,
+			expectedTtl:         "1d",
+			expectedRatio:       0,
+			expectedFactors:     []float64{0.8},
+			expectedCreation:    creation,
+			expectedUpdate:      time.Time{},
+			expectedExpiresAt:   deadline.Add(-time.Hour),
+		},
+		{
+			name:                "leader",
+			parts:               []RawEnvPart{with(short, aggregationLeader, false), with(long, aggregationLeader, true)},
+			expectedAggregation: aggregationLeader,
+			expectedTtl:         "1d",
+			expectedRatio:       1.5,
+			expectedFactors:     []float64{0.8, 0.9},
+			expectedCreation:    creation.Add(time.Hour),
+			expectedUpdate:      creation.Add(2 * time.Hour),
+			expectedExpiresAt:   deadline.Add(time.Hour),
+		},
+		{
+			name:                "short-lived leader",
+			parts:               []RawEnvPart{with(short, aggregationLeader, true), long},
+			expectedAggregation: aggregationLeader,
+			expectedTtl:         "1h",
+			expectedRatio:       0.5,
+			expectedFactors:     []float64{0.5, 0.8},
+			expectedCreation:    creation,
+			expectedUpdate:      creation.Add(time.Hour),
+			expectedExpiresAt:   deadline,
+		},
+		{
+			name:                "leader without leader falls back to max",
+			parts:               []RawEnvPart{with(short, aggregationLeader, false), long},
+			expectedAggregation: aggregationMax,
+			expectedTtl:         "1d",
+			expectedRatio:       1.5,
+			expectedFactors:     []float64{0.5, 0.8, 0.9},
+			expectedCreation:    creation.Add(time.Hour),
+			expectedUpdate:      creation.Add(2 * time.Hour),
+			expectedExpiresAt:   deadline.Add(time.Hour),
+		},
+		{
+			name:                "two leaders fall back to max",
+			parts:               []RawEnvPart{with(short, aggregationLeader, true), with(long, "", true)},
+			expectedAggregation: aggregationMax,
+			expectedTtl:         "1d",
+			expectedRatio:       1.5,
+			expectedFactors:     []float64{0.5, 0.8, 0.9},
+			expectedCreation:    creation.Add(time.Hour),
+			expectedUpdate:      creation.Add(2 * time.Hour),
+			expectedExpiresAt:   deadline.Add(time.Hour),
+		},
+		{
+			name:                "conflicting strategies fall back to max",
+			parts:               []RawEnvPart{with(short, aggregationMin, false), with(long, aggregationLeader, true)},
+			expectedAggregation: aggregationMax,
+			expectedTtl:         "1d",
+			expectedRatio:       1.5,
+			expectedFactors:     []float64{0.5, 0.8, 0.9},
+			expectedCreation:    creation.Add(time.Hour),
+			expectedUpdate:      creation.Add(2 * time.Hour),
+			expectedExpiresAt:   deadline.Add(time.Hour),
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			rawEnv := aggregateRawEnv(testCase.parts)
+			if rawEnv.Aggregation != testCase.expectedAggregation {
+				t.Errorf("Expected Aggregation %q, got %q", testCase.expectedAggregation, rawEnv.Aggregation)
+			}
+			if len(rawEnv.Namespaces) != len(testCase.parts) {
+				t.Errorf("Expected every namespace in the group, got %d", len(rawEnv.Namespaces))
+			}
+			if rawEnv.Ttl != testCase.expectedTtl {
+				t.Errorf("Expected Ttl %q, got %q", testCase.expectedTtl, rawEnv.Ttl)
+			}
+			if rawEnv.ReplenishRatio != testCase.expectedRatio {
+				t.Errorf("Expected ReplenishRatio %v, got %v", testCase.expectedRatio, rawEnv.ReplenishRatio)
+			}
+			if !slices.Equal(rawEnv.NotificationFactors, testCase.expectedFactors) {
+				t.Errorf("Expected NotificationFactors %v, got %v", testCase.expectedFactors, rawEnv.NotificationFactors)
+			}
+			if !rawEnv.CreationTimestamp.Equal(testCase.expectedCreation) {
+				t.Errorf("Expected CreationTimestamp %v, got %v", testCase.expectedCreation, rawEnv.CreationTimestamp)
+			}
+			if !rawEnv.UpdateTimestamp.Equal(testCase.expectedUpdate) {
+				t.Errorf("Expected UpdateTimestamp %v, got %v", testCase.expectedUpdate, rawEnv.UpdateTimestamp)
+			}
+			if !rawEnv.ExpiresAt.Equal(testCase.expectedExpiresAt) {
+				t.Errorf("Expected ExpiresAt %v, got %v", testCase.expectedExpiresAt, rawEnv.ExpiresAt)
+			}
+		})
+	}
+}
+
+func TestGetScheduledDeadline(t *testing.T) {
+	anchor := time.Date(2026, 10, 12, 12, 0, 0, 0, time.UTC) // Monday
+	schedules := []string{"0 20 * * *", "0 20 * * FRI"}
+	tests := []struct {
+		aggregation string
+		expected    time.Time
+	}{
+		{aggregationMax, time.Date(2026, 10, 16, 20, 0, 0, 0, time.UTC)},
+		{aggregationMin, time.Date(2026, 10, 12, 20, 0, 0, 0, time.UTC)},
+		{aggregationLeader, time.Date(2026, 10, 16, 20, 0, 0, 0, time.UTC)},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.aggregation, func(t *testing.T) {
+			result, err := getScheduledDeadline(schedules, anchor, testCase.aggregation)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !result.Equal(testCase.expected) {
+				t.Errorf("Expected %v, got %v", testCase.expected, result)
+			}
+		})
+	}
+}
+
+func TestUpdateRawEnvHold(t *testing.T) {
+	since := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := since.Add(24 * time.Hour)
+	recordedPart := RawEnvPart{Name: "ns1", Hold: true, HoldSince: since, HoldUntil: until, HoldReason: "demo"}
+	unrecordedPart := RawEnvPart{Name: "ns2", Hold: true, HoldUntil: until.Add(time.Hour), HoldReason: "demo"}
+	indefinitePart := RawEnvPart{Name: "ns3", Hold: true, HoldSince: since.Add(time.Hour)}
+
+	rawEnv := updateRawEnvHold(updateRawEnvHold(RawEnv{}, recordedPart), unrecordedPart)
+	if !rawEnv.Hold || !rawEnv.HoldSince.Equal(since) || !rawEnv.HoldUntil.Equal(until.Add(time.Hour)) {
+		t.Errorf("Unexpected merged hold: %+v", rawEnv)
+	}
+	if len(rawEnv.HoldReasons) != 1 {
+		t.Errorf("Expected deduplicated hold reasons, got %v", rawEnv.HoldReasons)
+	}
+	if len(rawEnv.UnrecordedHoldNamespaces) != 1 || rawEnv.UnrecordedHoldNamespaces[0] != "ns2" {
+		t.Errorf("Expected ns2 to need hold.since, got %v", rawEnv.UnrecordedHoldNamespaces)
+	}
+
+	rawEnv = updateRawEnvHold(rawEnv, indefinitePart)
+	if !rawEnv.HoldUntil.IsZero() || !rawEnv.HoldSince.Equal(since) {
+		t.Errorf("Expected indefinite hold from %v, got %+v", since, rawEnv)
+	}
+}
+
+func TestApplyHold(t *testing.T) {
+	since := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	deadline := since.Add(2 * time.Hour)
+	tests := []struct {
+		name     string
+		since    time.Time
+		until    time.Time
+		expected time.Time
+	}{
+		{name: "lapsed hold resumes remaining ttl", since: since, until: since.Add(24 * time.Hour), expected: deadline.Add(24 * time.Hour)},
+		{name: "indefinite hold", since: since, expected: time.Time{}},
+		{name: "hold after expiry", since: deadline.Add(time.Minute), until: deadline.Add(time.Hour), expected: deadline},
+		{name: "until before since", since: since, until: since.Add(-time.Hour), expected: deadline},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			if result := applyHold(timer.WallClock{}, deadline, testCase.since, testCase.until); !result.Equal(testCase.expected) {
+				t.Errorf("Expected %v, got %v", testCase.expected, result)
+			}
+		})
+	}
+}
+
+func TestGetExpiresAt(t *testing.T) {
+	anchor := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	created := anchor.Add(-24 * time.Hour)
+	tests := []struct {
+		name        string
+		rawEnv      RawEnv
+		maxLifetime time.Duration
+		expected    time.Time
+	}{
+		{name: "ttl only", rawEnv: RawEnv{Ttl: "1h"}, expected: anchor.Add(time.Hour)},
+		{name: "expiresAt only", rawEnv: RawEnv{ExpiresAt: anchor.Add(3 * time.Hour)}, expected: anchor.Add(3 * time.Hour)},
+		{name: "expiresAt caps ttl", rawEnv: RawEnv{Ttl: "2h", ExpiresAt: anchor.Add(time.Hour)}, expected: anchor.Add(time.Hour)},
+		{name: "ttl runs out before expiresAt", rawEnv: RawEnv{Ttl: "1h", ExpiresAt: anchor.Add(2 * time.Hour)}, expected: anchor.Add(time.Hour)},
+		{
+			name:        "maxLifetime caps extended ttl",
+			rawEnv:      RawEnv{Ttl: "2h", FirstCreationTimestamp: created},
+			maxLifetime: 25 * time.Hour,
+			expected:    created.Add(25 * time.Hour),
+		},
+		{
+			name:     "schedule only",
+			rawEnv:   RawEnv{Schedules: []string{"0 20 * * FRI"}},
+			expected: time.Date(2026, 1, 2, 20, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "latest schedule in group",
+			rawEnv:   RawEnv{Schedules: []string{"0 20 * * FRI", "0 8 * * MON"}},
+			expected: time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "schedule fires before ttl",
+			rawEnv:   RawEnv{Ttl: "72h", Schedules: []string{"0 20 * * FRI"}},
+			expected: time.Date(2026, 1, 2, 20, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "ttl runs out before schedule",
+			rawEnv:   RawEnv{Ttl: "1h", Schedules: []string{"0 20 * * FRI"}},
+			expected: anchor.Add(time.Hour),
+		},
+		{
+			name:     "lapsed hold",
+			rawEnv:   RawEnv{Ttl: "1h", Hold: true, HoldSince: anchor.Add(30 * time.Minute), HoldUntil: anchor.Add(5 * time.Hour)},
+			expected: anchor.Add(5*time.Hour + 30*time.Minute),
+		},
+		{
+			name:     "ended holds move the deadline",
+			rawEnv:   RawEnv{Ttl: "1h", HeldFor: 90 * time.Minute},
+			expected: anchor.Add(150 * time.Minute),
+		},
+		{
+			name:        "maxLifetime ends indefinite hold",
+			rawEnv:      RawEnv{Ttl: "1h", Hold: true, HoldSince: anchor, FirstCreationTimestamp: created},
+			maxLifetime: 48 * time.Hour,
+			expected:    created.Add(48 * time.Hour),
+		},
+		{
+			name:        "maxLifetime above ttl",
+			rawEnv:      RawEnv{Ttl: "2h", FirstCreationTimestamp: created},
+			maxLifetime: 48 * time.Hour,
+			expected:    anchor.Add(2 * time.Hour),
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			result, err := getExpiresAt(testCase.rawEnv, timer.WallClock{}, anchor, testCase.maxLifetime)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !result.Equal(testCase.expected) {
+				t.Errorf("Expected %v, got %v", testCase.expected, result)
+			}
+		})
+	}
+}
+
+func TestGetDefaults(t *testing.T) {
+	tests := []struct {
+		name            string
+		ttl             string
+		ratio           string
+		factors         string
+		expectedTtl     string
+		expectedRatio   float64
+		expectedFactors []float64
+	}{
+		{name: "unset", expectedTtl: "", expectedRatio: defaultReplenishRatio, expectedFactors: []float64{}},
+		{name: "set", ttl: "168h", ratio: "0", factors: "[0.5,0.9]", expectedTtl: "7d", expectedRatio: 0, expectedFactors: []float64{0.5, 0.9}},
+		{name: "invalid", ttl: "week", ratio: "-1", factors: "0.5", expectedTtl: "", expectedRatio: defaultReplenishRatio, expectedFactors: nil},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Setenv("DEFAULT_TTL", testCase.ttl)
+			t.Setenv("DEFAULT_REPLENISH_RATIO", testCase.ratio)
+			t.Setenv("DEFAULT_NOTIFICATION_FACTORS", testCase.factors)
+			if ttl := getDefaultTtl(); ttl != testCase.expectedTtl {
+				t.Errorf("Expected default ttl %q, got %q", testCase.expectedTtl, ttl)
+			}
+			if ratio := getDefaultReplenishRatio(); ratio != testCase.expectedRatio {
+				t.Errorf("Expected default ratio %v, got %v", testCase.expectedRatio, ratio)
+			}
+			if factors := getDefaultNotificationFactors(); !slices.Equal(factors, testCase.expectedFactors) {
+				t.Errorf("Expected default factors %v, got %v", testCase.expectedFactors, factors)
+			}
+		})
+	}
+}
+
+func TestGetMinLifetime(t *testing.T) {
+	tests := []struct {
+		name     string
+		a        time.Duration
+		b        time.Duration
+		expected time.Duration
+	}{
+		{name: "both unset", expected: 0},
+		{name: "only a", a: time.Hour, expected: time.Hour},
+		{name: "only b", b: time.Hour, expected: time.Hour},
+		{name: "stricter wins", a: time.Hour, b: 2 * time.Hour, expected: time.Hour},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			if result := getMinLifetime(testCase.a, testCase.b); result != testCase.expected {
+				t.Errorf("Expected %v, got %v", testCase.expected, result)
+			}
+		})
+	}
+}
+
 func makeNamespace(name, envName, ttl, replenishRatio, notificationFactors, updateTimestamp string, creation time.Time, managed string) *core.Namespace {
 	return &core.Namespace{
 		ObjectMeta: meta.ObjectMeta{
@@ -254,16 +880,25 @@ func makeZarfNamespace(name, envName, ttl, replenishRatio, notificationFactors,
 	return ns
 }
 
+// newNamespaceLister builds a lister over the namespaces, like the informer cache
+func newNamespaceLister(namespaces ...*core.Namespace) listers.NamespaceLister {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, ns := range namespaces {
+		_ = indexer.Add(ns)
+	}
+	return listers.NewNamespaceLister(indexer)
+}
+
 func TestGetEnvs(t *testing.T) {
 	validTime := time.Now().UTC().Format(time.RFC3339)
 	notificationFactors, _ := json.Marshal([]float64{0.5, 0.8})
 
 	t.Run("single valid namespace", func(t *testing.T) {
-		client := fake.NewSimpleClientset(
+		lister := newNamespaceLister(
 			makeNamespace("ns1", "env1", "1h", "1.5", string(notificationFactors), validTime, time.Now().Add(-2*time.Hour), "true"),
 		)
 		labelsSet := labels.Set{"kelm.riftonix.io/managed": "true"}
-		envs, err := getEnvs(client, labelsSet)
+		envs, err := getEnvs(lister, labelsSet)
 		if err != nil {
 			t.Fatalf("Expected no error, got %v", err)
 		}
@@ -279,12 +914,12 @@ func TestGetEnvs(t *testing.T) {
 	})
 
 	t.Run("multiple namespaces, different envs", func(t *testing.T) {
-		client := fake.NewSimpleClientset(
+		lister := newNamespaceLister(
 			makeNamespace("ns1", "env1", "1h", "1.5", string(notificationFactors), validTime, time.Now().Add(-2*time.Hour), "true"),
 			makeNamespace("ns2", "env2", "2h", "2.0", string(notificationFactors), validTime, time.Now().Add(-1*time.Hour), "true"),
 		)
 		labelsSet := labels.Set{"kelm.riftonix.io/managed": "true"}
-		envs, err := getEnvs(client, labelsSet)
+		envs, err := getEnvs(lister, labelsSet)
 		if err != nil {
 			t.Fatalf("Expected no error, got %v", err)
 		}
@@ -297,12 +932,12 @@ func TestGetEnvs(t *testing.T) {
 	})
 
 	t.Run("one env with two namespaces", func(t *testing.T) {
-		client := fake.NewSimpleClientset(
+		lister := newNamespaceLister(
 			makeNamespace("ns1", "env1", "1h", "1.5", `[0.5,0.8]`, time.Now().UTC().Format(time.RFC3339), time.Now().Add(-2*time.Hour), "true"),
 			makeNamespace("ns2", "env1", "2h", "2.0", `[0.5,0.8]`, time.Now().UTC().Format(time.RFC3339), time.Now().Add(-1*time.Hour), "true"),
 		)
 		labelsSet := labels.Set{"kelm.riftonix.io/managed": "true"}
-		envs, err := getEnvs(client, labelsSet)
+		envs, err := getEnvs(lister, labelsSet)
 		if err != nil {
 			t.Fatalf("Expected no error, got %v", err)
 		}
@@ -326,12 +961,12 @@ func TestGetEnvs(t *testing.T) {
 	})
 
 	t.Run("namespace with invalid annotation is skipped", func(t *testing.T) {
-		client := fake.NewSimpleClientset(
+		lister := newNamespaceLister(
 			makeNamespace("ns1", "env1", "1h", "bad", string(notificationFactors), validTime, time.Now().Add(-2*time.Hour), "true"),
 			makeNamespace("ns2", "env2", "2h", "2.0", string(notificationFactors), validTime, time.Now().Add(-1*time.Hour), "true"),
 		)
 		labelsSet := labels.Set{"kelm.riftonix.io/managed": "true"}
-		envs, err := getEnvs(client, labelsSet)
+		envs, err := getEnvs(lister, labelsSet)
 		if err != nil {
 			t.Fatalf("Expected no error, got %v", err)
 		}
@@ -345,10 +980,10 @@ func TestGetEnvs(t *testing.T) {
 
 	t.Run("zarf namespace propagates IsZarf and package info", func(t *testing.T) {
 		t.Setenv("ZARF_ENABLED", "true")
-		client := fake.NewSimpleClientset(
+		lister := newNamespaceLister(
 			makeZarfNamespace("ns1", "env1", "1h", "1.5", string(notificationFactors), validTime, time.Now().Add(-2*time.Hour), "my-pkg"),
 		)
-		envs, err := getEnvs(client, labels.Set{"kelm.riftonix.io/managed": "true"})
+		envs, err := getEnvs(lister, labels.Set{"kelm.riftonix.io/managed": "true"})
 		if err != nil {
 			t.Fatalf("Expected no error, got %v", err)
 		}
@@ -364,16 +999,271 @@ func TestGetEnvs(t *testing.T) {
 		}
 	})
 
-	t.Run("client returns error", func(t *testing.T) {
-		// Use a fake client that returns error on List
-		client := &fake.Clientset{}
-		client.PrependReactor("list", "namespaces", func(action k8stesting.Action) (handled bool, ret runtime.Object, err error) {
-			return true, nil, errors.New("list error")
-		})
-		labelsSet := labels.Set{"kelm.riftonix.io/managed": "true"}
-		_, err := getEnvs(client, labelsSet)
-		if err == nil {
-			t.Fatal("Expected error from client, got nil")
+	t.Run("updateTimestamp extends env", func(t *testing.T) {
+		updateTime := time.Now().Add(-15 * time.Minute).UTC().Format(time.RFC3339)
+		lister := newNamespaceLister(
+			makeNamespace("ns1", "env1", "1h", "0.5", string(notificationFactors), updateTime, time.Now().Add(-70*time.Minute), "true"),
+		)
+		envs, err := getEnvs(lister, labels.Set{"kelm.riftonix.io/managed": "true"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		env := envs["env1"]
+		// The update restores 30m: anchor 40m ago, 1h ttl = ~20m
+		if env.RemainingTtl < 19*time.Minute || env.RemainingTtl > 21*time.Minute {
+			t.Errorf("Expected RemainingTtl around 20m, got %v", env.RemainingTtl)
+		}
+		if len(env.UnrecordedReplenishedNamespaces) != 1 || !env.Replenished.Anchor.Equal(env.AnchorTimestamp) {
+			t.Errorf("Expected replenished anchor to be recorded on ns1, got %+v %v", env.Replenished, env.UnrecordedReplenishedNamespaces)
+		}
+		if len(env.RemainingNotificationsTtl) != 2 || env.RemainingNotificationsTtl[0] != 0 {
+			t.Errorf("Expected expired 0.5 notification and pending 0.8 notification, got %v", env.RemainingNotificationsTtl)
+		}
+	})
+
+	t.Run("early updateTimestamp does not replenish", func(t *testing.T) {
+		updateTime := time.Now().Add(-90 * time.Minute).UTC().Format(time.RFC3339)
+		lister := newNamespaceLister(
+			makeNamespace("ns1", "env1", "1h", "0.75", string(notificationFactors), updateTime, time.Now().Add(-2*time.Hour), "true"),
+		)
+		envs, err := getEnvs(lister, labels.Set{"kelm.riftonix.io/managed": "true"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		// Update after 30m of 1h ttl is below the 0.75 ratio, env stays expired
+		if envs["env1"].RemainingTtl != 0 {
+			t.Errorf("Expected RemainingTtl 0, got %v", envs["env1"].RemainingTtl)
+		}
+	})
+
+	t.Run("expiresAt drives countdown and notifications", func(t *testing.T) {
+		ns := makeNamespace("ns1", "env1", "", "0", `[0.5]`, time.Now().Add(-time.Hour).UTC().Format(time.RFC3339), time.Now().Add(-2*time.Hour), "true")
+		ns.Annotations["kelm.riftonix.io/expiresAt"] = time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+		lister := newNamespaceLister(ns)
+		envs, err := getEnvs(lister, labels.Set{"kelm.riftonix.io/managed": "true"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		env := envs["env1"]
+		if env.RemainingTtl < 59*time.Minute || env.RemainingTtl > time.Hour {
+			t.Errorf("Expected RemainingTtl around 1h, got %v", env.RemainingTtl)
+		}
+		// Lifetime is 2h from update to deadline, 0.5 notification is due now
+		if len(env.RemainingNotificationsTtl) != 1 || env.RemainingNotificationsTtl[0] > time.Second {
+			t.Errorf("Expected due notification, got %v", env.RemainingNotificationsTtl)
+		}
+	})
+
+	t.Run("cluster-wide maxLifetime caps extended env", func(t *testing.T) {
+		t.Setenv("MAX_LIFETIME", "3h")
+		lister := newNamespaceLister(
+			makeNamespace("ns1", "env1", "2h", "0", `[]`, time.Now().UTC().Format(time.RFC3339), time.Now().Add(-2*time.Hour), "true"),
+		)
+		envs, err := getEnvs(lister, labels.Set{"kelm.riftonix.io/managed": "true"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		env := envs["env1"]
+		// Extended to 2h from now, but capped at 3h after creation
+		if env.RemainingTtl < 59*time.Minute || env.RemainingTtl > time.Hour {
+			t.Errorf("Expected RemainingTtl around 1h, got %v", env.RemainingTtl)
+		}
+		if env.MaxLifetime != 3*time.Hour {
+			t.Errorf("Expected MaxLifetime 3h, got %v", env.MaxLifetime)
+		}
+	})
+
+	t.Run("indefinitely held env has no deadline", func(t *testing.T) {
+		ns := makeNamespace("ns1", "env1", "1h", "0", `[0.5]`, validTime, time.Now().Add(-2*time.Hour), "true")
+		ns.Annotations["kelm.riftonix.io/hold"] = "true"
+		lister := newNamespaceLister(ns)
+		envs, err := getEnvs(lister, labels.Set{"kelm.riftonix.io/managed": "true"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		env := envs["env1"]
+		if !isEnvHeld(env) || len(env.RemainingNotificationsTtl) != 0 {
+			t.Errorf("Expected held env without notifications, got %+v", env)
+		}
+		if env.HoldSince.IsZero() || len(env.UnrecordedHoldNamespaces) != 1 {
+			t.Errorf("Expected hold to start now and be recorded on ns1, got %+v", env)
+		}
+	})
+
+	t.Run("released hold resumes the remaining ttl", func(t *testing.T) {
+		// 30m of TTL were left when the hold started 2h30m ago, the hold annotation is removed now
+		created := time.Now().Add(-3 * time.Hour)
+		ns := makeNamespace("ns1", "env1", "1h", "0", `[]`, "", created, "true")
+		ns.Annotations["kelm.riftonix.io/hold.since"] = created.Add(30 * time.Minute).UTC().Format(time.RFC3339)
+		lister := newNamespaceLister(ns)
+		envs, err := getEnvs(lister, labels.Set{"kelm.riftonix.io/managed": "true"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		env := envs["env1"]
+		if env.Hold || env.RemainingTtl < 29*time.Minute || env.RemainingTtl > 31*time.Minute {
+			t.Errorf("Expected released env with ~30m left, got hold %v and %v", env.Hold, env.RemainingTtl)
+		}
+		if env.HeldFor < 149*time.Minute || env.HeldFor > 151*time.Minute || !slices.Equal(env.ReleasedHoldNamespaces, []string{"ns1"}) {
+			t.Errorf("Expected ~2h30m held time to be recorded on ns1, got %v on %v", env.HeldFor, env.ReleasedHoldNamespaces)
+		}
+	})
+
+	t.Run("lapsed hold resumes at hold.until", func(t *testing.T) {
+		created := time.Now().Add(-3 * time.Hour).UTC().Truncate(time.Second)
+		ns := makeNamespace("ns1", "env1", "1h", "0", `[]`, "", created, "true")
+		ns.Annotations["kelm.riftonix.io/hold"] = "true"
+		ns.Annotations["kelm.riftonix.io/hold.since"] = created.Add(30 * time.Minute).Format(time.RFC3339)
+		ns.Annotations["kelm.riftonix.io/hold.until"] = created.Add(90 * time.Minute).Format(time.RFC3339)
+		lister := newNamespaceLister(ns)
+		envs, err := getEnvs(lister, labels.Set{"kelm.riftonix.io/managed": "true"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		env := envs["env1"]
+		if env.HeldFor != time.Hour || !env.ExpiresAt.Equal(created.Add(2*time.Hour)) {
+			t.Errorf("Expected 1h held and deadline %v, got %v and %v", created.Add(2*time.Hour), env.HeldFor, env.ExpiresAt)
+		}
+	})
+
+	t.Run("released hold is counted once", func(t *testing.T) {
+		created := time.Now().Add(-3 * time.Hour)
+		ns := makeNamespace("ns1", "env1", "1h", "0", `[]`, "", created, "true")
+		ns.Annotations["kelm.riftonix.io/status.held"] = "2h30m"
+		lister := newNamespaceLister(ns)
+		envs, err := getEnvs(lister, labels.Set{"kelm.riftonix.io/managed": "true"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		env := envs["env1"]
+		if env.RemainingTtl < 29*time.Minute || env.RemainingTtl > 30*time.Minute || len(env.ReleasedHoldNamespaces) != 0 {
+			t.Errorf("Expected ~30m left without a new release, got %v and %v", env.RemainingTtl, env.ReleasedHoldNamespaces)
+		}
+	})
+
+	t.Run("new hold freezes from its own start", func(t *testing.T) {
+		// 30m were left after an earlier 1h hold, the new hold starts now and ends in 1h
+		created := time.Now().Add(-90 * time.Minute)
+		ns := makeNamespace("ns1", "env1", "1h", "0", `[]`, "", created, "true")
+		ns.Annotations["kelm.riftonix.io/status.held"] = "1h"
+		ns.Annotations["kelm.riftonix.io/hold"] = "true"
+		ns.Annotations["kelm.riftonix.io/hold.until"] = time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+		lister := newNamespaceLister(ns)
+		envs, err := getEnvs(lister, labels.Set{"kelm.riftonix.io/managed": "true"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		env := envs["env1"]
+		if env.RemainingTtl < 89*time.Minute || env.RemainingTtl > 91*time.Minute {
+			t.Errorf("Expected ~1h30m left, got %v", env.RemainingTtl)
+		}
+		if len(env.UnrecordedHoldNamespaces) != 1 {
+			t.Errorf("Expected the new hold start to be recorded on ns1, got %v", env.UnrecordedHoldNamespaces)
+		}
+	})
+
+	t.Run("business clock pauses outside working hours", func(t *testing.T) {
+		// Today is a day off, so the TTL does not run out until the next working day
+		now := time.Now().UTC()
+		var workdays []string
+		for day := (now.Weekday() + 1) % 7; day != now.Weekday(); day = (day + 1) % 7 {
+			workdays = append(workdays, day.String()[:3])
+		}
+		t.Setenv("BUSINESS_HOURS", strings.Join(workdays, ",")+" 00:00-23:59 UTC")
+		ns := makeNamespace("ns1", "env1", "2h", "0", `[]`, now.Add(-2*time.Hour).Format(time.RFC3339), now.Add(-3*time.Hour), "true")
+		ns.Annotations["kelm.riftonix.io/ttl.clock"] = "business"
+		lister := newNamespaceLister(ns)
+		envs, err := getEnvs(lister, labels.Set{"kelm.riftonix.io/managed": "true"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		env := envs["env1"]
+		if env.Clock != "business" {
+			t.Errorf("Expected business clock, got %q", env.Clock)
+		}
+		if env.RemainingTtl < time.Hour {
+			t.Errorf("Expected TTL to survive closed hours, got %v", env.RemainingTtl)
+		}
+	})
+
+	t.Run("notify channels and owners are merged", func(t *testing.T) {
+		first := makeNamespace("ns1", "env1", "1h", "1.5", string(notificationFactors), validTime, time.Now(), "true")
+		first.Annotations["kelm.riftonix.io/notify.channel"] = "#team-a"
+		second := makeNamespace("ns2", "env1", "1h", "1.5", string(notificationFactors), validTime, time.Now(), "true")
+		second.Annotations["kelm.riftonix.io/notify.channel"] = "#team-b"
+		third := makeNamespace("ns3", "env1", "1h", "1.5", string(notificationFactors), validTime, time.Now(), "true")
+		third.Annotations["kelm.riftonix.io/notify.channel"] = "#team-a"
+		first.Annotations["kelm.riftonix.io/owner.email"] = "alice@example.com"
+		second.Annotations["kelm.riftonix.io/owner.email"] = "bob@example.com,alice@example.com"
+		first.Annotations["kelm.riftonix.io/notify"] = "email:owner"
+		third.Annotations["kelm.riftonix.io/notify"] = "email,slack:#team-c"
+		lister := newNamespaceLister(first, second, third)
+		envs, err := getEnvs(lister, labels.Set{"kelm.riftonix.io/managed": "true"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if channels := envs["env1"].NotifyChannels; !slices.Equal(channels, []string{"#team-a", "#team-b"}) {
+			t.Errorf("Expected unique channels, got %v", channels)
+		}
+		if owners := envs["env1"].OwnerEmails; !slices.Equal(owners, []string{"alice@example.com", "bob@example.com"}) {
+			t.Errorf("Expected unique owners, got %v", owners)
+		}
+		if routes := envs["env1"].NotifyRoutes; !slices.Equal(routes, []string{"email:owner", "chat:#team-c"}) {
+			t.Errorf("Expected unique routes, got %v", routes)
+		}
+	})
+
+	t.Run("notified factors of the current anchor", func(t *testing.T) {
+		created := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+		first := makeNamespace("ns1", "env1", "2h", "1.5", string(notificationFactors), created.Format(time.RFC3339), created, "true")
+		first.Annotations["kelm.riftonix.io/status.notified"] = fmt.Sprintf(`{"anchor":%q,"factors":[0.5]}`, created.Format(time.RFC3339))
+		second := makeNamespace("ns2", "env1", "2h", "1.5", string(notificationFactors), created.Format(time.RFC3339), created, "true")
+		second.Annotations["kelm.riftonix.io/status.notified"] = `{"anchor":"2020-01-01T00:00:00Z","factors":[0.8],"warned":"2020-01-02T00:00:00Z"}`
+		third := makeNamespace("ns3", "env1", "2h", "1.5", string(notificationFactors), created.Format(time.RFC3339), created, "true")
+		third.Annotations["kelm.riftonix.io/status.notified"] = "broken"
+		lister := newNamespaceLister(first, second, third)
+		envs, err := getEnvs(lister, labels.Set{"kelm.riftonix.io/managed": "true"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if factors := envs["env1"].NotifiedFactors; !slices.Equal(factors, []float64{0.5}) {
+			t.Errorf("Expected only factors of the current anchor, got %v", factors)
+		}
+		if warned := envs["env1"].WarnedDeadline; !warned.Equal(time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("Expected warning of an older anchor to be kept, got %v", warned)
+		}
+	})
+
+	t.Run("leader namespace drives env ttl", func(t *testing.T) {
+		leader := makeNamespace("ns1", "env1", "1h", "1.5", string(notificationFactors), validTime, time.Now(), "true")
+		leader.Annotations["kelm.riftonix.io/env.aggregation"] = "leader"
+		leader.Annotations["kelm.riftonix.io/env.leader"] = "true"
+		follower := makeNamespace("ns2", "env1", "24h", "1.5", string(notificationFactors), validTime, time.Now(), "true")
+		lister := newNamespaceLister(leader, follower)
+		envs, err := getEnvs(lister, labels.Set{"kelm.riftonix.io/managed": "true"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		env := envs["env1"]
+		if env.Aggregation != aggregationLeader || len(env.Namespaces) != 2 {
+			t.Fatalf("Expected leader env with 2 namespaces, got %+v", env)
+		}
+		if env.RemainingTtl > time.Hour {
+			t.Errorf("Expected leader 1h TTL, got %v", env.RemainingTtl)
+		}
+	})
+
+	t.Run("selects cached namespaces by labels", func(t *testing.T) {
+		lister := newNamespaceLister(
+			makeNamespace("ns1", "env1", "1h", "1.5", string(notificationFactors), validTime, time.Now(), "true"),
+			makeNamespace("ns2", "env2", "1h", "1.5", string(notificationFactors), validTime, time.Now(), "true"),
+		)
+		envs, err := getEnvs(lister, labels.Set{"kelm.riftonix.io/managed": "true", "kelm.riftonix.io/env.name": "env2"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(envs) != 1 || !slices.Equal(envs["env2"].Namespaces, []string{"ns2"}) {
+			t.Errorf("Expected only env2, got %v", envs)
 		}
 	})
 }
@@ -0,0 +1,156 @@
+package kelm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"kelm/internal/pkg/k8s"
+
+	"github.com/sirupsen/logrus"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcore "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+// Kubernetes Event reasons recorded on managed namespaces
+const (
+	reasonRegistered         = "Registered"
+	reasonInvalidAnnotations = "InvalidAnnotations"
+	reasonExpiring           = "Expiring"
+	reasonDeletionStarted    = "DeletionStarted"
+	reasonDeleted            = "Deleted"
+	reasonForceDeleted       = "ForceDeleted"
+	reasonNotFound           = "NotFound"
+	reasonDeletionTimeout    = "DeletionTimeout"
+	reasonDeletionFailed     = "DeletionFailed"
+	reasonInvalidTemplates   = "InvalidTemplates"
+)
+
+// Event recorder for managed namespaces, nil disables events
+var recorder record.EventRecorder
+
+// Last recorded registration state per namespace, so resyncs do not repeat events.
+// Registrations are also recorded in kelm.riftonix.io/status.registered, so restarts do not repeat them.
+var namespaceStates = make(map[string]string)
+var namespaceStatesMu sync.Mutex
+
+func newEventRecorder(client kubernetes.Interface) record.EventRecorder {
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcore.EventSinkImpl{Interface: client.CoreV1().Events("")})
+	broadcaster.StartLogging(logrus.Debugf)
+	return broadcaster.NewRecorder(scheme.Scheme, core.EventSource{Component: "kelm"})
+}
+
+// namespaceReference refers to the namespace by UID as well, kubectl describe matches events on it
+func namespaceReference(namespace string, uid types.UID) *core.ObjectReference {
+	return &core.ObjectReference{APIVersion: "v1", Kind: "Namespace", Name: namespace, UID: uid}
+}
+
+func recordNamespaceEvent(namespace string, uid types.UID, eventType string, reason string, messageFmt string, args ...any) {
+	if recorder == nil {
+		return
+	}
+	recorder.Eventf(namespaceReference(namespace, uid), eventType, reason, messageFmt, args...)
+}
+
+func recordConfigMapEvent(configMap *core.ConfigMap, eventType string, reason string, message string) {
+	if recorder == nil {
+		return
+	}
+	reference := &core.ObjectReference{APIVersion: "v1", Kind: "ConfigMap", Namespace: configMap.Namespace, Name: configMap.Name, UID: configMap.UID}
+	recorder.Event(reference, eventType, reason, message)
+}
+
+// recordNamespaceState records an event only when the namespace state differs from the last recorded one
+func recordNamespaceState(ns core.Namespace, state string, eventType string, reason string, message string) {
+	namespaceStatesMu.Lock()
+	changed := namespaceStates[ns.Name] != state
+	namespaceStates[ns.Name] = state
+	namespaceStatesMu.Unlock()
+	if changed {
+		recordNamespaceEvent(ns.Name, ns.UID, eventType, reason, "%s", message)
+	}
+}
+
+func forgetNamespaceState(namespace string) {
+	namespaceStatesMu.Lock()
+	defer namespaceStatesMu.Unlock()
+	delete(namespaceStates, namespace)
+}
+
+func recordNamespaceRegistered(rawEnvPart RawEnvPart) {
+	recordNamespaceState(
+		rawEnvPart.NsData,
+		"registered:"+rawEnvPart.EnvName,
+		core.EventTypeNormal,
+		reasonRegistered,
+		fmt.Sprintf("Namespace is managed by kelm as part of env '%s'", rawEnvPart.EnvName),
+	)
+}
+
+// recordRegistered stores the env name on namespaces that joined it, so a restart does not report them again
+func recordRegistered(client kubernetes.Interface, env Env) error {
+	patch := fmt.Sprintf(`{"metadata":{"annotations":{"kelm.riftonix.io/status.registered":%q}}}`, env.Name)
+	var errs []error
+	for _, ns := range env.UnregisteredNamespaces {
+		_, err := client.CoreV1().Namespaces().Patch(context.Background(), ns, types.MergePatchType, []byte(patch), meta.PatchOptions{})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("record registration on namespace %s: %w", ns, err))
+			continue
+		}
+		logrus.Infof("Registration with env '%s' recorded on namespace %s", env.Name, ns)
+	}
+	return errors.Join(errs...)
+}
+
+func recordNamespaceInvalid(ns core.Namespace, err error) {
+	recordNamespaceState(ns, "invalid:"+err.Error(), core.EventTypeWarning, reasonInvalidAnnotations, err.Error())
+}
+
+func recordEnvExpiring(env Env) {
+	for _, ns := range env.Namespaces {
+		recordNamespaceEvent(ns, env.NamespaceUIDs[ns], core.EventTypeNormal, reasonExpiring, "Env '%s' expires at %s", env.Name, env.ExpiresAt.Format(time.RFC3339))
+	}
+}
+
+func recordDeletionStarted(env Env, namespaces []string) {
+	for _, ns := range namespaces {
+		recordNamespaceEvent(ns, env.NamespaceUIDs[ns], core.EventTypeNormal, reasonDeletionStarted, "Env '%s' expired, deleting namespaces %v", env.Name, namespaces)
+	}
+}
+
+func recordDeleteResults(env Env, results []k8s.NamespaceDeleteResult) {
+	envName := env.Name
+	for _, result := range results {
+		uid := env.NamespaceUIDs[result.Namespace]
+		switch result.State {
+		case "deleted":
+			recordNamespaceEvent(result.Namespace, uid, core.EventTypeNormal, reasonDeleted, "Namespace of env '%s' deleted in %s", envName, result.Duration.Round(time.Second))
+		case "force-deleted":
+			recordNamespaceEvent(result.Namespace, uid, core.EventTypeNormal, reasonForceDeleted, "Namespace of env '%s' deleted after finalizers removal in %s", envName, result.Duration.Round(time.Second))
+		case "not-found":
+			recordNamespaceEvent(result.Namespace, uid, core.EventTypeNormal, reasonNotFound, "Namespace of env '%s' was already deleted", envName)
+		case "timeout":
+			recordNamespaceEvent(result.Namespace, uid, core.EventTypeWarning, reasonDeletionTimeout, "Namespace of env '%s' was not deleted in time, retry is scheduled", envName)
+		default:
+			recordNamespaceEvent(result.Namespace, uid, core.EventTypeWarning, reasonDeletionFailed, "Failed to delete namespace of env '%s': %v", envName, getDeleteResultError(result))
+		}
+		if result.State != "timeout" && result.State != "error" {
+			forgetNamespaceState(result.Namespace)
+		}
+	}
+}
+
+func getDeleteResultError(result k8s.NamespaceDeleteResult) error {
+	if result.FinalizerError != nil {
+		return result.FinalizerError
+	}
+	return result.DeletionError
+}
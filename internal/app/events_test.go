@@ -0,0 +1,200 @@
+package kelm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"kelm/internal/pkg/k8s"
+
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+func useRecorder(t *testing.T) *record.FakeRecorder {
+	fakeRecorder := record.NewFakeRecorder(100)
+	previous := recorder
+	recorder = fakeRecorder
+	namespaceStatesMu.Lock()
+	namespaceStates = make(map[string]string)
+	namespaceStatesMu.Unlock()
+	t.Cleanup(func() { recorder = previous })
+	return fakeRecorder
+}
+
+func drainEvents(fakeRecorder *record.FakeRecorder) []string {
+	var events []string
+	for {
+		select {
+		case event := <-fakeRecorder.Events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func TestRecordNamespaceState(t *testing.T) {
+	fakeRecorder := useRecorder(t)
+	part := RawEnvPart{Name: "ns1", EnvName: "env1", NsData: core.Namespace{ObjectMeta: meta.ObjectMeta{Name: "ns1"}}}
+
+	recordNamespaceRegistered(part)
+	recordNamespaceRegistered(part)
+	if events := drainEvents(fakeRecorder); len(events) != 1 || !strings.HasPrefix(events[0], "Normal Registered") {
+		t.Errorf("Expected one Registered event, got %v", events)
+	}
+
+	recordNamespaceInvalid(core.Namespace{ObjectMeta: meta.ObjectMeta{Name: "ns1"}}, errors.New("bad ttl"))
+	recordNamespaceInvalid(core.Namespace{ObjectMeta: meta.ObjectMeta{Name: "ns1"}}, errors.New("bad ttl"))
+	if events := drainEvents(fakeRecorder); len(events) != 1 || events[0] != "Warning InvalidAnnotations bad ttl" {
+		t.Errorf("Expected one InvalidAnnotations event, got %v", events)
+	}
+
+	recordNamespaceRegistered(part)
+	forgetNamespaceState("ns1")
+	recordNamespaceRegistered(part)
+	if events := drainEvents(fakeRecorder); len(events) != 2 {
+		t.Errorf("Expected Registered event after fix and after forget, got %v", events)
+	}
+}
+
+func TestRecordDeleteResults(t *testing.T) {
+	fakeRecorder := useRecorder(t)
+	results := []k8s.NamespaceDeleteResult{
+		{Namespace: "ns1", State: "deleted", Duration: 3 * time.Second},
+		{Namespace: "ns2", State: "force-deleted"},
+		{Namespace: "ns3", State: "not-found"},
+		{Namespace: "ns4", State: "timeout"},
+		{Namespace: "ns5", State: "error", DeletionError: errors.New("forbidden")},
+	}
+	expected := []string{
+		"Normal Deleted",
+		"Normal ForceDeleted",
+		"Normal NotFound",
+		"Warning DeletionTimeout",
+		"Warning DeletionFailed",
+	}
+
+	recordDeleteResults(Env{Name: "env1"}, results)
+	events := drainEvents(fakeRecorder)
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %d events, got %v", len(expected), events)
+	}
+	for i, prefix := range expected {
+		if !strings.HasPrefix(events[i], prefix) {
+			t.Errorf("Expected event %d to start with %q, got %q", i, prefix, events[i])
+		}
+	}
+	if !strings.Contains(events[4], "forbidden") {
+		t.Errorf("Expected deletion error in event, got %q", events[4])
+	}
+}
+
+func TestGetEnvsRecordsEvents(t *testing.T) {
+	fakeRecorder := useRecorder(t)
+	validTime := time.Now().UTC().Format(time.RFC3339)
+	lister := newNamespaceLister(
+		makeNamespace("ns1", "env1", "1h", "bad", "[0.5]", validTime, time.Now(), "true"),
+		makeNamespace("ns2", "env2", "1h", "1.5", "[0.5]", validTime, time.Now(), "true"),
+	)
+	if _, err := getEnvs(lister, labels.Set{"kelm.riftonix.io/managed": "true"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	events := strings.Join(drainEvents(fakeRecorder), "\n")
+	if !strings.Contains(events, "Warning InvalidAnnotations failed to parse namespace ns1") {
+		t.Errorf("Expected InvalidAnnotations event for ns1, got %v", events)
+	}
+	if !strings.Contains(events, "Normal Registered Namespace is managed by kelm as part of env 'env2'") {
+		t.Errorf("Expected Registered event for ns2, got %v", events)
+	}
+}
+
+func TestGetEnvsRegisteredOnce(t *testing.T) {
+	fakeRecorder := useRecorder(t)
+	ns := makeNamespace("ns1", "env1", "1h", "0.5", "[0.5]", time.Now().UTC().Format(time.RFC3339), time.Now(), "true")
+	client := fake.NewSimpleClientset(ns)
+	envs, err := getEnvs(newNamespaceLister(ns), labels.Set{"kelm.riftonix.io/managed": "true"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	recordRegistered(client, envs["env1"])
+	if events := drainEvents(fakeRecorder); len(events) != 1 || !strings.HasPrefix(events[0], "Normal Registered") {
+		t.Fatalf("Expected one Registered event, got %v", events)
+	}
+
+	// A restart forgets the states in memory, the recorded registration is kept
+	recorded, err := client.CoreV1().Namespaces().Get(context.Background(), "ns1", meta.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected namespace, got %v", err)
+	}
+	fakeRecorder = useRecorder(t)
+	envs, err = getEnvs(newNamespaceLister(recorded), labels.Set{"kelm.riftonix.io/managed": "true"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if events := drainEvents(fakeRecorder); len(events) != 0 || len(envs["env1"].UnregisteredNamespaces) != 0 {
+		t.Errorf("Expected no Registered event after restart, got %v and %v", events, envs["env1"].UnregisteredNamespaces)
+	}
+}
+
+// referenceRecorder keeps the objects events are recorded for
+type referenceRecorder struct {
+	mu         sync.Mutex
+	references []*core.ObjectReference
+}
+
+func (r *referenceRecorder) Event(object runtime.Object, _, _, _ string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.references = append(r.references, object.(*core.ObjectReference))
+}
+
+func (r *referenceRecorder) Eventf(object runtime.Object, eventType, reason, _ string, _ ...any) {
+	r.Event(object, eventType, reason, "")
+}
+
+func (r *referenceRecorder) AnnotatedEventf(object runtime.Object, _ map[string]string, eventType, reason, _ string, _ ...any) {
+	r.Event(object, eventType, reason, "")
+}
+
+func TestNamespaceEventsReferToUID(t *testing.T) {
+	useRecorder(t)
+	references := &referenceRecorder{}
+	recorder = references
+	ns := makeNamespace("ns1", "env1", "1h", "0.5", "[0.5]", time.Now().UTC().Format(time.RFC3339), time.Now(), "true")
+	ns.UID = "uid-1"
+	envs, err := getEnvs(newNamespaceLister(ns), labels.Set{"kelm.riftonix.io/managed": "true"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	env := envs["env1"]
+	recordEnvExpiring(env)
+	recordDeletionStarted(env, env.Namespaces)
+	recordDeleteResults(env, []k8s.NamespaceDeleteResult{{Namespace: "ns1", State: "deleted"}})
+
+	// Registered, Expiring, DeletionStarted and Deleted
+	if len(references.references) != 4 {
+		t.Fatalf("Expected 4 events, got %d", len(references.references))
+	}
+	for _, reference := range references.references {
+		if reference.Kind != "Namespace" || reference.Name != "ns1" || reference.UID != "uid-1" {
+			t.Errorf("Expected reference to namespace ns1 with its UID, got %+v", reference)
+		}
+	}
+}
+
+func TestRecorderDisabled(t *testing.T) {
+	previous := recorder
+	recorder = nil
+	defer func() { recorder = previous }()
+	// Must not panic without a recorder
+	recordDeletionStarted(Env{Name: "env1"}, []string{"ns1"})
+	recordEnvExpiring(Env{Name: "env1", Namespaces: []string{"ns1"}})
+}
@@ -2,11 +2,18 @@ package kelm
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
+	"os/signal"
+	"slices"
 	"sync"
+	"syscall"
 	"time"
 
 	"kelm/internal/pkg/k8s"
+	"kelm/internal/pkg/notify"
+	"kelm/internal/pkg/timer"
 	"kelm/internal/pkg/zarf"
 
 	"github.com/sirupsen/logrus"
@@ -14,35 +21,33 @@ import (
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	listers "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
-type CountdownCancel struct {
-	envName string
-	ttl     int
-	cancel  context.CancelFunc
-}
-
 // Set for tracking namespaces being deleted by operator
 var deletingNamespaces = make(map[string]struct{})
 var deletingNamespacesMu sync.RWMutex
-var countdownsMu sync.Mutex
 
 func getRetryDelay() time.Duration {
 	return getDurationEnv("RETRY_DELAY", time.Hour)
 }
 
-func getWatchRetryDelay() time.Duration {
-	return getDurationEnv("WATCH_RETRY_DELAY", 10*time.Second)
-}
-
 func getResyncInterval() time.Duration {
 	return getDurationEnv("RESYNC_INTERVAL", 5*time.Minute)
 }
 
+// getMaxLifetime returns the cluster-wide env lifetime cap, 0 means no cap
+func getMaxLifetime() time.Duration {
+	return getDurationEnv("MAX_LIFETIME", 0)
+}
+
 func getZarfNamespace() string {
 	namespace := os.Getenv("ZARF_NAMESPACE")
 	if namespace == "" {
@@ -56,13 +61,13 @@ func getDurationEnv(name string, fallback time.Duration) time.Duration {
 	if s == "" {
 		return fallback
 	}
-	d, err := time.ParseDuration(s)
+	d, err := timer.ParseDuration(s)
 	if err != nil {
-		logrus.Warnf("Invalid %s %q, using %v: %v", name, s, fallback, err)
+		logrus.Warnf("Invalid %s %q, using %s: %v", name, s, timer.FormatDuration(fallback), err)
 		return fallback
 	}
 	if d <= 0 {
-		logrus.Warnf("Invalid %s %q, using %v: duration must be positive", name, s, fallback)
+		logrus.Warnf("Invalid %s %q, using %s: duration must be positive", name, s, timer.FormatDuration(fallback))
 		return fallback
 	}
 	return d
@@ -87,205 +92,357 @@ func Init() {
 		logrus.Errorf("Failed to create clientset: %v\n", err)
 		os.Exit(1)
 	}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
 	logrus.Info("Operator launched")
 	logrus.Infof("Ignoring namespaces: %s", ignoredNamespaces)
 	logrus.Infof("Zarf integration enabled: %v", isZarfEnabled())
-	logrus.Infof("Retry delay: %v", getRetryDelay())
-	logrus.Infof("Watch retry delay: %v", getWatchRetryDelay())
-	logrus.Infof("Resync interval: %v", getResyncInterval())
+	logrus.Infof("Retry delay: %s", timer.FormatDuration(getRetryDelay()))
+	logrus.Infof("Resync interval: %s", timer.FormatDuration(getResyncInterval()))
+	logrus.Infof("Max lifetime: %s", timer.FormatDuration(getMaxLifetime()))
 	logrus.Infof("Zarf namespace: %s", getZarfNamespace())
-	envs, err := getEnvs(client, labels.Set{"kelm.riftonix.io/managed": "true"})
-	if err != nil {
-		logrus.Errorf("Failed to get namespaces: %v", err)
-		os.Exit(1)
+	logrus.Infof("Shutdown grace period: %s", timer.FormatDuration(getShutdownGracePeriod()))
+	notifier = getNotifier()
+	recorder = newEventRecorder(client)
+	emitter = getEmitter()
+	if namespace, name, ok := getTemplatesConfigMap(); ok && notifier != nil {
+		logrus.Infof("Notification templates: ConfigMap %s/%s", namespace, name)
+		go watchTemplates(ctx, client, namespace, name)
 	}
-	countdowns := make([]CountdownCancel, 0)
-	for _, env := range envs {
-		startCountdown(client, &countdowns, env, int(env.RemainingTtl.Seconds()))
+	scheduler := NewScheduler()
+	if !isLeaderElectionEnabled() {
+		WatchWithContext(ctx, client, scheduler)
+		shutdown(client)
+		return
 	}
-	go Watch(client, &countdowns)
-	select {}
+	// The Lease is kept until running deletions are done, so a follower does not start them twice
+	electionCtx, stopElection := context.WithCancel(context.Background())
+	electionDone := make(chan struct{})
+	go func() {
+		defer close(electionDone)
+		err := runWithLeaderElection(electionCtx, client, func(leaderCtx context.Context) {
+			watchCtx, cancel := context.WithCancel(leaderCtx)
+			defer cancel()
+			stopOnShutdown := context.AfterFunc(ctx, cancel)
+			defer stopOnShutdown()
+			WatchWithContext(watchCtx, client, scheduler)
+			// The Lease was lost without shutdown, deletions of this term must not run unrecorded under the next leader
+			if ctx.Err() == nil {
+				stepDown(client)
+			}
+		})
+		if err != nil {
+			logrus.Errorf("Failed to start leader election: %v", err)
+			os.Exit(1)
+		}
+	}()
+	<-ctx.Done()
+	// Wait for the leader term to stop its countdowns
+	leadMu.Lock()
+	shutdown(client)
+	leadMu.Unlock()
+	stopElection()
+	<-electionDone
 }
 
-func Watch(client *kubernetes.Clientset, countdowns *[]CountdownCancel) {
-	WatchWithContext(context.Background(), client, countdowns)
+func Watch(client *kubernetes.Clientset, scheduler *Scheduler) {
+	WatchWithContext(context.Background(), client, scheduler)
 }
 
-func WatchWithContext(ctx context.Context, client *kubernetes.Clientset, countdowns *[]CountdownCancel) {
+// WatchWithContext keeps an informer cache of managed namespaces and reconciles envs through a work queue.
+// Namespace events and every RESYNC_INTERVAL enqueue env names, RECONCILE_WORKERS workers sync their countdowns.
+// Reconnects and relists are handled by the informer. Countdowns are cancelled when ctx is done,
+// after the running reconciles have finished, so none of them schedules a countdown afterwards.
+func WatchWithContext(ctx context.Context, client *kubernetes.Clientset, scheduler *Scheduler) {
+	// A later leader term starts from the cache, not from countdowns of this one
+	defer scheduler.CancelAll()
+	queue := newReconcileQueue()
+	setReconcileQueue(queue)
+	defer setReconcileQueue(nil)
+	var workers sync.WaitGroup
+	defer workers.Wait()
+	defer queue.ShutDownWithDrain()
+
+	factory := informers.NewSharedInformerFactoryWithOptions(client, 0, informers.WithTweakListOptions(func(options *meta.ListOptions) {
+		options.LabelSelector = "kelm.riftonix.io/managed=true"
+	}))
+	namespaces := factory.Core().V1().Namespaces()
+	lister := namespaces.Lister()
+	_, err := namespaces.Informer().AddEventHandler(cache.ResourceEventHandlerDetailedFuncs{
+		AddFunc: func(obj any, isInInitialList bool) {
+			// The initial list is enqueued at once after the cache sync
+			if !isInInitialList {
+				handleNamespaceEvent(watch.Added, obj)
+			}
+		},
+		UpdateFunc: func(_, obj any) {
+			handleNamespaceEvent(watch.Modified, obj)
+		},
+		DeleteFunc: func(obj any) {
+			handleNamespaceEvent(watch.Deleted, obj)
+		},
+	})
+	if err != nil {
+		logrus.Errorf("Failed to add namespace event handler: %v", err)
+		return
+	}
+	factory.Start(ctx.Done())
+	defer factory.Shutdown()
+	if !cache.WaitForCacheSync(ctx.Done(), namespaces.Informer().HasSynced) {
+		logrus.Infof("Stopping namespace watch: %v", ctx.Err())
+		return
+	}
+	logrus.Debug("Namespace cache synced")
+	resyncCountdowns(lister, scheduler)
+	for range getReconcileWorkers() {
+		workers.Go(func() {
+			runReconcileWorker(queue, func(envName string) error {
+				return reconcileEnv(client, lister, scheduler, envName)
+			})
+		})
+	}
+	go scheduler.Run(ctx)
+
 	resyncTicker := time.NewTicker(getResyncInterval())
 	defer resyncTicker.Stop()
-
 	for {
-		if err := ctx.Err(); err != nil {
-			logrus.Infof("Stopping namespace watch: %v", err)
+		select {
+		case <-ctx.Done():
+			logrus.Infof("Stopping namespace watch: %v", ctx.Err())
 			return
+		case <-resyncTicker.C:
+			resyncCountdowns(lister, scheduler)
 		}
-
-		watchInterface, err := client.CoreV1().Namespaces().Watch(ctx, meta.ListOptions{
-			LabelSelector: "kelm.riftonix.io/managed=true",
-		})
-		if err != nil {
-			logrus.Errorf("Failed to start watch: %v", err)
-			waitForWatchRetry(ctx)
-			continue
-		}
-
-		logrus.Debug("Namespace watch started")
-		watchClosed := false
-		for !watchClosed {
-			select {
-			case <-ctx.Done():
-				watchInterface.Stop()
-				logrus.Infof("Stopping namespace watch: %v", ctx.Err())
-				return
-			case <-resyncTicker.C:
-				resyncCountdowns(client, countdowns)
-			case event, ok := <-watchInterface.ResultChan():
-				if !ok {
-					watchClosed = true
-					logrus.Warn("Namespace watch channel closed, reconnecting")
-					continue
-				}
-				handleNamespaceEvent(client, countdowns, event)
-			}
-		}
-		watchInterface.Stop()
-		waitForWatchRetry(ctx)
 	}
 }
 
-func handleNamespaceEvent(client *kubernetes.Clientset, countdowns *[]CountdownCancel, event watch.Event) {
-	ns, ok := event.Object.(*core.Namespace)
+// handleNamespaceEvent enqueues the env of a valid namespace
+func handleNamespaceEvent(eventType watch.EventType, obj any) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	ns, ok := obj.(*core.Namespace)
 	if !ok {
-		logrus.Warnf("Unexpected object type in watch event %s", event.Type)
+		logrus.Warnf("Unexpected object type in watch event %s", eventType)
 		return
 	}
+	if eventType == watch.Deleted {
+		forgetNamespaceState(ns.Name)
+	}
 	// Ignore events for namespaces being deleted by operator
 	if isNamespaceDeleting(ns.Name) {
-		logrus.Debugf("Ignoring event %s for namespace %s (deletion in progress)", event.Type, ns.Name)
+		logrus.Debugf("Ignoring event %s for namespace %s (deletion in progress)", eventType, ns.Name)
 		return
 	}
 	namespace, err := handleNamespace(*ns)
 	if err != nil && !kerrors.IsNotFound(err) {
 		logrus.Warningf("%v", err)
+		if eventType != watch.Deleted {
+			recordNamespaceInvalid(*ns, err)
+		}
 		return
 	}
-	envName := namespace.EnvName
-	logrus.Infof("Event %s for namespace %s with env.name=%s", event.Type, ns.Name, envName)
-
-	// Cancel existing countdowns for this env and recalculate
-	cancelCountdownsForEnv(countdowns, envName)
+	logrus.Infof("Event %s for namespace %s with env.name=%s", eventType, ns.Name, namespace.EnvName)
+	enqueueEnv(namespace.EnvName)
+}
 
-	envs, err := getEnvs(client, labels.Set{
+// reconcileEnv syncs the env countdowns with the cached namespaces.
+// The queue never runs it for one env on two workers at once.
+func reconcileEnv(client *kubernetes.Clientset, lister listers.NamespaceLister, scheduler *Scheduler, envName string) error {
+	envs, err := getEnvs(lister, labels.Set{
 		"kelm.riftonix.io/managed":  "true",
 		"kelm.riftonix.io/env.name": envName,
 	})
-	if kerrors.IsNotFound(err) {
-		logrus.Infof("Env '%s' was empty and removed", envName)
-		return
-	}
 	if err != nil {
-		logrus.Errorf("Failed to get namespaces for env.name=%s: %v", envName, err)
-		return
+		return fmt.Errorf("get namespaces for env.name=%s: %w", envName, err)
 	}
-
-	for _, env := range envs {
-		startCountdown(client, countdowns, env, int(env.RemainingTtl.Seconds()))
+	env, ok := envs[envName]
+	if ok && isEnvDeleting(env) {
+		// The delete callback enqueues the env again when it is done
+		logrus.Debugf("Skipping reconcile of env '%s' (deletion in progress)", envName)
+		return nil
 	}
-}
-
-func waitForWatchRetry(ctx context.Context) {
-	delay := getWatchRetryDelay()
-	select {
-	case <-ctx.Done():
-	case <-time.After(delay):
+	if !ok {
+		scheduler.CancelEnv(envName)
+		logrus.Infof("Env '%s' was empty and removed", envName)
+		forgetEmittedEnv(envName)
+		return nil
 	}
+	// Countdowns are synced even if recording fails, the failed writes are retried with the env
+	return errors.Join(
+		recordRegistered(client, env),
+		recordHoldSince(client, env),
+		recordHoldRelease(client, env),
+		scheduleEnv(client, scheduler, env),
+	)
 }
 
-func resyncCountdowns(client *kubernetes.Clientset, countdowns *[]CountdownCancel) {
+// resyncCountdowns enqueues every cached env and every env that still has countdowns
+func resyncCountdowns(lister listers.NamespaceLister, scheduler *Scheduler) {
 	logrus.Debug("Resyncing namespace countdowns")
-	envs, err := getEnvs(client, labels.Set{"kelm.riftonix.io/managed": "true"})
+	namespaces, err := lister.List(labels.SelectorFromSet(labels.Set{"kelm.riftonix.io/managed": "true"}))
 	if err != nil {
 		logrus.Errorf("Failed to resync namespaces: %v", err)
 		return
 	}
-	cancelAllCountdowns(countdowns)
-	for _, env := range envs {
-		startCountdown(client, countdowns, env, int(env.RemainingTtl.Seconds()))
+	for _, ns := range namespaces {
+		if envName := ns.Labels["kelm.riftonix.io/env.name"]; envName != "" {
+			enqueueEnv(envName)
+		}
+	}
+	for _, envName := range scheduler.EnvNames() {
+		enqueueEnv(envName)
 	}
 }
 
-// startCountdown registers and launches a deletion countdown for the given env.
-func startCountdown(client *kubernetes.Clientset, countdowns *[]CountdownCancel, env Env, ttlSeconds int) {
-	ctx, cancel := context.WithCancel(context.Background())
-	countdownsMu.Lock()
-	*countdowns = append(*countdowns, CountdownCancel{
-		envName: env.Name,
-		cancel:  cancel,
-		ttl:     ttlSeconds,
-	})
-	countdownsMu.Unlock()
-	go CreateCountdown(ctx, env, ttlSeconds, "removal", makeDeleteCallback(client, countdowns, env))
+// scheduleEnv syncs the removal and notification countdowns of a freshly built env.
+// Unchanged countdowns stay as they are, moved ones are updated in place.
+// It returns the failed status writes, the countdowns are synced anyway.
+func scheduleEnv(client *kubernetes.Clientset, scheduler *Scheduler, env Env) error {
+	err := errors.Join(recordReplenished(client, env), notifyIfExtended(client, env), emitEnvScheduled(client, env))
+	var countdowns []Countdown
+	if countdown, ok := getRemovalCountdown(client, env); ok {
+		countdowns = append(countdowns, countdown)
+	}
+	countdowns = append(countdowns, getNotificationCountdowns(client, env)...)
+	scheduler.SyncEnv(env.Name, countdowns)
+	return err
 }
 
-func cancelCountdownsForEnv(countdowns *[]CountdownCancel, envName string) {
-	countdownsMu.Lock()
-	defer countdownsMu.Unlock()
+// getRemovalCountdown returns the deletion countdown of the env, ok is false for a held env.
+// An expired env is due at once.
+func getRemovalCountdown(client *kubernetes.Clientset, env Env) (Countdown, bool) {
+	if isEnvHeld(env) {
+		logrus.Infof("Env '%s' is on hold (%s), countdown is frozen", env.Name, env.HoldReason)
+		return Countdown{}, false
+	}
+	// The deadline does not move between resyncs, so the countdown stays untouched
+	fireAt := env.ExpiresAt
+	if env.RemainingTtl <= 0 {
+		fireAt = time.Now()
+	}
+	// Another leader started the deletion, it either finishes, records the interruption or runs out of time
+	if handover := env.DeletionStarted.Add(getDeletionHandover()); !env.DeletionStarted.IsZero() && fireAt.Before(handover) {
+		logrus.Infof("Env '%s' deletion started at %s by a previous leader, waiting for it until %s", env.Name, env.DeletionStarted.Format(time.RFC3339), handover.Format(time.RFC3339))
+		fireAt = handover
+	}
+	return Countdown{
+		Scenario:   scenarioRemoval,
+		FireAt:     fireAt,
+		Namespaces: env.Namespaces,
+		Callback:   makeDeleteCallback(client, env),
+	}, true
+}
 
-	filtered := (*countdowns)[:0]
-	for _, cd := range *countdowns {
-		if cd.envName == envName {
-			cd.cancel()
+// recordHoldSince stores the hold start on held namespaces that miss it,
+// so the frozen TTL survives resyncs and operator restarts.
+func recordHoldSince(client kubernetes.Interface, env Env) error {
+	var errs []error
+	for _, ns := range env.UnrecordedHoldNamespaces {
+		patch := fmt.Sprintf(`{"metadata":{"annotations":{"kelm.riftonix.io/hold.since":%q}}}`, env.HoldSince.Format(time.RFC3339))
+		_, err := client.CoreV1().Namespaces().Patch(context.Background(), ns, types.MergePatchType, []byte(patch), meta.PatchOptions{})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("record hold start on namespace %s: %w", ns, err))
 			continue
 		}
-		filtered = append(filtered, cd)
+		logrus.Infof("Env '%s' hold started at %s, recorded on namespace %s", env.Name, env.HoldSince.Format(time.RFC3339), ns)
 	}
-	*countdowns = filtered
+	return errors.Join(errs...)
 }
 
-func cancelAllCountdowns(countdowns *[]CountdownCancel) {
-	countdownsMu.Lock()
-	defer countdownsMu.Unlock()
-
-	for _, cd := range *countdowns {
-		cd.cancel()
+// recordHoldRelease stores the held time on the env namespaces and drops the start of the ended hold,
+// so the remaining TTL survives resyncs and a later hold starts afresh.
+func recordHoldRelease(client kubernetes.Interface, env Env) error {
+	if len(env.ReleasedHoldNamespaces) == 0 {
+		return nil
+	}
+	patch := fmt.Sprintf(`{"metadata":{"annotations":{"kelm.riftonix.io/hold.since":null,"kelm.riftonix.io/status.held":%q}}}`, timer.FormatDuration(env.HeldFor))
+	var errs []error
+	for _, ns := range env.Namespaces {
+		_, err := client.CoreV1().Namespaces().Patch(context.Background(), ns, types.MergePatchType, []byte(patch), meta.PatchOptions{})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("record hold release on namespace %s: %w", ns, err))
+			continue
+		}
+		logrus.Infof("Env '%s' hold ended, held for %s in total, recorded on namespace %s", env.Name, timer.FormatDuration(env.HeldFor), ns)
 	}
-	*countdowns = (*countdowns)[:0]
+	return errors.Join(errs...)
 }
 
 // makeDeleteCallback builds the deletion callback for an env.
 // Namespace deletion failures are retried after RETRY_DELAY.
-func makeDeleteCallback(client *kubernetes.Clientset, countdowns *[]CountdownCancel, env Env) DeleteNamespacesCallback {
+// Results are reported after the deletion is finished, so slow sinks do not hold back a shutdown.
+func makeDeleteCallback(client *kubernetes.Clientset, env Env) CountdownCallback {
 	return func(namespaces []string) {
-		for _, ns := range namespaces {
-			markNamespaceDeleting(ns)
+		report := deleteEnv(client, env, namespaces)
+		emitEnvDeleteResults(env, report)
+		if !report.RetryAt.IsZero() {
+			sendTeardownReport(env, notify.KindDeletionFailed, report)
+			return
 		}
-		defer func() {
-			for _, ns := range namespaces {
-				unmarkNamespaceDeleting(ns)
-			}
-		}()
-
-		if env.IsZarf {
-			if err := zarf.RemovePackage(context.Background(), env.ZarfPackageName); err != nil {
-				if kerrors.IsNotFound(err) {
-					logrus.Warnf("Zarf package %q is not found in cluster, assuming it already removed", env.ZarfPackageName)
-				} else {
-					logrus.Errorf("Failed to remove zarf package %q: %v", env.ZarfPackageName, err)
-					deleteZarfPackageSecret(client, env.ZarfPackageName)
-				}
-			}
-			if err := zarf.PruneImages(context.Background()); err != nil {
-				logrus.Errorf("Failed to prune zarf registry images: %v", err)
-			}
+		// Namespace events were ignored during the deletion
+		enqueueEnv(env.Name)
+		sendTeardownReport(env, notify.KindDeleted, report)
+	}
+}
+
+// deleteEnv removes the env Zarf package and namespaces and returns the teardown report,
+// with the retry time when a namespace was not deleted
+func deleteEnv(client *kubernetes.Clientset, env Env, namespaces []string) notify.TeardownReport {
+	startDeletion(env)
+	defer finishDeletion(env.Name)
+	if !env.DeletionInterrupted.IsZero() {
+		logrus.Infof("Resuming deletion of env '%s' interrupted at %s", env.Name, env.DeletionInterrupted.Format(time.RFC3339))
+	}
+	// Mark namespaces first, so their events do not schedule a second deletion
+	for _, ns := range namespaces {
+		markNamespaceDeleting(ns)
+	}
+	defer func() {
+		for _, ns := range namespaces {
+			unmarkNamespaceDeleting(ns)
 		}
+	}()
+	recordRunningDeletion(client, env, namespaces)
+	defer recordFinishedDeletion(client, env, namespaces)
+	recordDeletionStarted(env, namespaces)
+	// Sent before the deletion starts, so consumers see expired before any deletion result
+	emitEnvExpired(env)
+
+	var zarfReport *notify.ZarfReport
+	if env.IsZarf {
+		zarfReport = removeZarfPackage(client, env.ZarfPackageName)
+	}
 
-		results := k8s.ForceDeleteNamespaces(client, namespaces, time.Minute, 5*time.Second)
-		if hasFailedDeletions(results) {
-			scheduleRetry(client, countdowns, env)
-			return
+	results := k8s.ForceDeleteNamespaces(client, namespaces, time.Minute, 5*time.Second)
+	recordDeleteResults(env, results)
+	report := notify.TeardownReport{Namespaces: newNamespaceReports(results), Zarf: zarfReport}
+	if hasFailedDeletions(results) {
+		report.RetryAt = scheduleRetry(env)
+		return report
+	}
+	forgetEnvNotifications(env.Name)
+	return report
+}
+
+// removeZarfPackage removes the env Zarf package and prunes the registry, failures are reported and do not stop the deletion
+func removeZarfPackage(client kubernetes.Interface, packageName string) *notify.ZarfReport {
+	report := &notify.ZarfReport{Package: packageName, Removal: notify.ZarfRemoved, Prune: notify.ZarfPruned}
+	if err := zarf.RemovePackage(context.Background(), packageName); err != nil {
+		if kerrors.IsNotFound(err) {
+			logrus.Warnf("Zarf package %q is not found in cluster, assuming it already removed", packageName)
+			report.Removal = notify.ZarfNotFound
+		} else {
+			logrus.Errorf("Failed to remove zarf package %q: %v", packageName, err)
+			report.Removal, report.RemovalError = notify.ZarfFailed, err.Error()
+			deleteZarfPackageSecret(client, packageName)
 		}
 	}
+	if err := zarf.PruneImages(context.Background()); err != nil {
+		logrus.Errorf("Failed to prune zarf registry images: %v", err)
+		report.Prune, report.PruneError = notify.ZarfFailed, err.Error()
+	}
+	return report
 }
 
 func deleteZarfPackageSecret(client kubernetes.Interface, packageName string) {
@@ -314,6 +471,11 @@ func unmarkNamespaceDeleting(namespace string) {
 	delete(deletingNamespaces, namespace)
 }
 
+// isEnvDeleting reports whether a delete callback is running for any env namespace
+func isEnvDeleting(env Env) bool {
+	return slices.ContainsFunc(env.Namespaces, isNamespaceDeleting)
+}
+
 func isNamespaceDeleting(namespace string) bool {
 	deletingNamespacesMu.RLock()
 	defer deletingNamespacesMu.RUnlock()
@@ -321,10 +483,13 @@ func isNamespaceDeleting(namespace string) bool {
 	return exists
 }
 
-func scheduleRetry(client *kubernetes.Clientset, countdowns *[]CountdownCancel, env Env) {
+// scheduleRetry enqueues the env after RETRY_DELAY and returns the retry time.
+// The env is expired by then, so its reconcile starts the deletion again.
+func scheduleRetry(env Env) time.Time {
 	delay := getRetryDelay()
-	logrus.Infof("Scheduling retry deletion for env '%s' in %v", env.Name, delay)
-	startCountdown(client, countdowns, env, int(delay.Seconds()))
+	logrus.Infof("Scheduling retry deletion for env '%s' in %s", env.Name, timer.FormatDuration(delay))
+	enqueueEnvAfter(env.Name, delay)
+	return time.Now().Add(delay)
 }
 
 func hasFailedDeletions(results []k8s.NamespaceDeleteResult) bool {
@@ -2,7 +2,9 @@ package kelm
 
 import (
 	"context"
+	"strings"
 	"testing"
+	"time"
 
 	core "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
@@ -35,6 +37,49 @@ func TestGetZarfNamespace(t *testing.T) {
 	})
 }
 
+func TestRecordHoldSince(t *testing.T) {
+	client := fake.NewSimpleClientset(&core.Namespace{ObjectMeta: meta.ObjectMeta{Name: "ns1"}})
+	since := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	recordHoldSince(client, Env{Name: "env1", HoldSince: since, UnrecordedHoldNamespaces: []string{"ns1"}})
+
+	ns, err := client.CoreV1().Namespaces().Get(context.Background(), "ns1", meta.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected namespace, got %v", err)
+	}
+	if got := ns.Annotations["kelm.riftonix.io/hold.since"]; got != "2026-10-16T12:00:00Z" {
+		t.Errorf("Expected recorded hold.since, got %q", got)
+	}
+
+	// A failed write is returned, so the reconcile is retried
+	err = recordHoldSince(client, Env{Name: "env1", HoldSince: since, UnrecordedHoldNamespaces: []string{"ns1", "gone"}})
+	if err == nil || !strings.Contains(err.Error(), "gone") {
+		t.Errorf("Expected error for the missing namespace, got %v", err)
+	}
+}
+
+func TestRecordHoldRelease(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&core.Namespace{ObjectMeta: meta.ObjectMeta{Name: "ns1", Annotations: map[string]string{"kelm.riftonix.io/hold.since": "2026-10-16T12:00:00Z"}}},
+		&core.Namespace{ObjectMeta: meta.ObjectMeta{Name: "ns2"}},
+	)
+
+	recordHoldRelease(client, Env{Name: "env1", Namespaces: []string{"ns1", "ns2"}, HeldFor: 150 * time.Minute, ReleasedHoldNamespaces: []string{"ns1"}})
+
+	for _, name := range []string{"ns1", "ns2"} {
+		ns, err := client.CoreV1().Namespaces().Get(context.Background(), name, meta.GetOptions{})
+		if err != nil {
+			t.Fatalf("Expected namespace, got %v", err)
+		}
+		if _, ok := ns.Annotations["kelm.riftonix.io/hold.since"]; ok {
+			t.Errorf("Expected hold.since to be removed from %s", name)
+		}
+		if got := ns.Annotations["kelm.riftonix.io/status.held"]; got != "2h30m" {
+			t.Errorf("Expected recorded status.held on %s, got %q", name, got)
+		}
+	}
+}
+
 func TestDeleteZarfPackageSecret(t *testing.T) {
 	t.Setenv("ZARF_NAMESPACE", "custom-zarf")
 	client := fake.NewSimpleClientset(&core.Secret{
@@ -0,0 +1,108 @@
+package kelm
+
+import (
+	"context"
+	"crypto/rand"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// Namespace of the pod, mounted with the service account token
+const serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// Held by the running leader term, a new term waits until the previous one has stopped
+var leadMu sync.Mutex
+
+func isLeaderElectionEnabled() bool {
+	return os.Getenv("LEADER_ELECTION_ENABLED") == "true"
+}
+
+func getLeaseName() string {
+	name := os.Getenv("LEADER_ELECTION_LEASE_NAME")
+	if name == "" {
+		return "kelm"
+	}
+	return name
+}
+
+// getLeaseNamespace returns LEADER_ELECTION_NAMESPACE, or the pod namespace
+func getLeaseNamespace() string {
+	if namespace := os.Getenv("LEADER_ELECTION_NAMESPACE"); namespace != "" {
+		return namespace
+	}
+	if data, err := os.ReadFile(serviceAccountNamespaceFile); err == nil {
+		return strings.TrimSpace(string(data))
+	}
+	return "default"
+}
+
+// getLeaseIdentity returns the pod name, which is the hostname in Kubernetes
+func getLeaseIdentity() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		return "kelm-" + strings.ToLower(rand.Text())
+	}
+	return hostname
+}
+
+func getLeaseDuration() time.Duration {
+	return getDurationEnv("LEADER_ELECTION_LEASE_DURATION", 15*time.Second)
+}
+
+func getLeaseRenewDeadline() time.Duration {
+	return getDurationEnv("LEADER_ELECTION_RENEW_DEADLINE", 10*time.Second)
+}
+
+func getLeaseRetryPeriod() time.Duration {
+	return getDurationEnv("LEADER_ELECTION_RETRY_PERIOD", 2*time.Second)
+}
+
+// runWithLeaderElection runs lead while this replica holds the Lease and campaigns again after losing it.
+// The lead context is cancelled when the Lease is lost. On ctx done the Lease is released, so a follower takes over at once.
+func runWithLeaderElection(ctx context.Context, client kubernetes.Interface, lead func(ctx context.Context)) error {
+	identity := getLeaseIdentity()
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock: &resourcelock.LeaseLock{
+			LeaseMeta:  meta.ObjectMeta{Name: getLeaseName(), Namespace: getLeaseNamespace()},
+			Client:     client.CoordinationV1(),
+			LockConfig: resourcelock.ResourceLockConfig{Identity: identity},
+		},
+		LeaseDuration:   getLeaseDuration(),
+		RenewDeadline:   getLeaseRenewDeadline(),
+		RetryPeriod:     getLeaseRetryPeriod(),
+		ReleaseOnCancel: true,
+		Name:            "kelm",
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				leadMu.Lock()
+				defer leadMu.Unlock()
+				logrus.Infof("Became leader as %s, starting countdowns", identity)
+				lead(ctx)
+			},
+			OnStoppedLeading: func() {
+				logrus.Infof("Stopped leading as %s", identity)
+			},
+			OnNewLeader: func(leader string) {
+				if leader != identity {
+					logrus.Infof("Following leader %s", leader)
+				}
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	logrus.Infof("Leader election enabled: Lease %s/%s, identity %s", getLeaseNamespace(), getLeaseName(), identity)
+	for ctx.Err() == nil {
+		elector.Run(ctx)
+	}
+	return nil
+}
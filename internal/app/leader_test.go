@@ -0,0 +1,75 @@
+package kelm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestGetLeaseNamespace(t *testing.T) {
+	t.Setenv("LEADER_ELECTION_NAMESPACE", "kelm-system")
+	if namespace := getLeaseNamespace(); namespace != "kelm-system" {
+		t.Errorf("Expected namespace from env, got %q", namespace)
+	}
+}
+
+func TestRunWithLeaderElection(t *testing.T) {
+	t.Setenv("LEADER_ELECTION_NAMESPACE", "kelm")
+	t.Setenv("LEADER_ELECTION_LEASE_NAME", "kelm-test")
+	t.Setenv("LEADER_ELECTION_LEASE_DURATION", "3s")
+	t.Setenv("LEADER_ELECTION_RENEW_DEADLINE", "2s")
+	t.Setenv("LEADER_ELECTION_RETRY_PERIOD", "100ms")
+	client := fake.NewSimpleClientset()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	leading := make(chan struct{})
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- runWithLeaderElection(ctx, client, func(ctx context.Context) {
+			close(leading)
+			<-ctx.Done()
+		})
+	}()
+	select {
+	case <-leading:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected to become leader")
+	}
+	lease, err := client.CoordinationV1().Leases("kelm").Get(ctx, "kelm-test", meta.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected Lease to be created, got %v", err)
+	}
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != getLeaseIdentity() {
+		t.Errorf("Expected Lease held by %s, got %v", getLeaseIdentity(), lease.Spec.HolderIdentity)
+	}
+
+	cancel()
+	select {
+	case err := <-stopped:
+		if err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected leader election to stop")
+	}
+	lease, err = client.CoordinationV1().Leases("kelm").Get(context.Background(), "kelm-test", meta.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get Lease: %v", err)
+	}
+	if lease.Spec.HolderIdentity != nil && *lease.Spec.HolderIdentity != "" {
+		t.Errorf("Expected Lease to be released, held by %s", *lease.Spec.HolderIdentity)
+	}
+}
+
+func TestRunWithLeaderElectionInvalidTimings(t *testing.T) {
+	t.Setenv("LEADER_ELECTION_LEASE_DURATION", "5s")
+	t.Setenv("LEADER_ELECTION_RENEW_DEADLINE", "10s")
+	err := runWithLeaderElection(context.Background(), fake.NewSimpleClientset(), func(context.Context) {})
+	if err == nil {
+		t.Error("Expected error when renew deadline exceeds lease duration")
+	}
+}
@@ -0,0 +1,444 @@
+package kelm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"kelm/internal/pkg/k8s"
+	"kelm/internal/pkg/notify"
+
+	"github.com/sirupsen/logrus"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Env lifecycle notifier, nil when notifications are disabled
+var notifier notify.Notifier
+
+// notifiedStatus - value of kelm.riftonix.io/status.notified, factors delivered for one TTL anchor.
+// Warned is the deadline of the last expiring notification, moving the deadline past it sends KindExtended,
+// so firing alerts get resolved. It is zero once the warning was resolved.
+type notifiedStatus struct {
+	Anchor  time.Time `json:"anchor"`
+	Factors []float64 `json:"factors"`
+	Warned  time.Time `json:"warned,omitzero"`
+}
+
+// Statuses this operator delivered, they cover the gap until patched namespaces come back from the API
+var notifiedCache = make(map[string]notifiedStatus)
+var notifiedCacheMu sync.Mutex
+
+const defaultNotifyRetries = 3
+
+func getNotifyTimeout() time.Duration {
+	return getDurationEnv("NOTIFY_TIMEOUT", 10*time.Second)
+}
+
+func getNotifyRetryDelay() time.Duration {
+	return getDurationEnv("NOTIFY_RETRY_DELAY", 5*time.Second)
+}
+
+func getNotifyRetries() int {
+	s := os.Getenv("NOTIFY_RETRIES")
+	if s == "" {
+		return defaultNotifyRetries
+	}
+	retries, err := strconv.Atoi(s)
+	if err != nil || retries < 0 {
+		logrus.Warnf("Invalid NOTIFY_RETRIES %q, using %d", s, defaultNotifyRetries)
+		return defaultNotifyRetries
+	}
+	return retries
+}
+
+func getChatFlavor() string {
+	flavor := os.Getenv("NOTIFY_CHAT_FLAVOR")
+	switch flavor {
+	case "":
+		return notify.FlavorSlack
+	case notify.FlavorSlack, notify.FlavorMattermost:
+		return flavor
+	}
+	logrus.Warnf("Invalid NOTIFY_CHAT_FLAVOR %q, using %q", flavor, notify.FlavorSlack)
+	return notify.FlavorSlack
+}
+
+// getNotifier returns the configured notifiers, nil if none is configured.
+// Webhook URLs often carry tokens, so the URLs themselves are not logged.
+func getNotifier() notify.Notifier {
+	var notifiers notify.Notifiers
+	if url := os.Getenv("NOTIFY_WEBHOOK_URL"); url != "" {
+		logrus.Info("Webhook notifications enabled")
+		webhook := notify.NewWebhook(url, getNotifyTimeout(), getNotifyRetries(), getNotifyRetryDelay())
+		webhook.Templates = notifyTemplates
+		notifiers = append(notifiers, webhook)
+	}
+	if url := os.Getenv("NOTIFY_CHAT_WEBHOOK_URL"); url != "" {
+		logrus.Infof("Chat notifications enabled: %s", getChatFlavor())
+		chat := notify.NewChat(url, getChatFlavor(), getNotifyTimeout(), getNotifyRetries(), getNotifyRetryDelay())
+		chat.Templates = notifyTemplates
+		notifiers = append(notifiers, chat)
+	}
+	if url := os.Getenv("NOTIFY_ALERTMANAGER_URL"); url != "" {
+		logrus.Info("Alertmanager notifications enabled")
+		alertmanager := notify.NewAlertmanager(url, getNotifyTimeout(), getNotifyRetries(), getNotifyRetryDelay())
+		alertmanager.Templates = notifyTemplates
+		notifiers = append(notifiers, alertmanager)
+	}
+	if addr := os.Getenv("NOTIFY_SMTP_ADDR"); addr != "" {
+		if email := getEmailNotifier(addr); email != nil {
+			email.Templates = notifyTemplates
+			notifiers = append(notifiers, email)
+		}
+	}
+	switch len(notifiers) {
+	case 0:
+		logrus.Info("Notifications disabled: no notifier is configured")
+		return nil
+	case 1:
+		return notifiers[0]
+	}
+	return notifiers
+}
+
+func getEmailNotifier(addr string) *notify.Email {
+	from := os.Getenv("NOTIFY_SMTP_FROM")
+	if from == "" {
+		logrus.Warn("Email notifications disabled: NOTIFY_SMTP_FROM is not set")
+		return nil
+	}
+	startTLS := true
+	if s := os.Getenv("NOTIFY_SMTP_STARTTLS"); s != "" {
+		parsed, err := strconv.ParseBool(s)
+		if err != nil {
+			logrus.Warnf("Invalid NOTIFY_SMTP_STARTTLS %q, using true", s)
+		} else {
+			startTLS = parsed
+		}
+	}
+	logrus.Infof("Email notifications enabled: %s, STARTTLS %v", addr, startTLS)
+	return notify.NewEmail(addr, from, os.Getenv("NOTIFY_SMTP_USERNAME"), os.Getenv("NOTIFY_SMTP_PASSWORD"), startTLS, getNotifyTimeout())
+}
+
+// getNotificationCountdowns returns a countdown for every upcoming notification factor that is not delivered yet.
+// Factors missed while the operator was down are delivered once, as the latest missed factor.
+func getNotificationCountdowns(client kubernetes.Interface, env Env) []Countdown {
+	if notifier == nil && recorder == nil && emitter == nil {
+		return nil
+	}
+	notified := getNotifiedFactors(env)
+	var countdowns []Countdown
+	var missed []float64
+	for i, factor := range env.NotificationFactors {
+		if slices.Contains(notified, factor) {
+			continue
+		}
+		if !env.NotificationTimestamps[i].After(time.Now()) {
+			missed = append(missed, factor)
+			continue
+		}
+		countdowns = append(countdowns, Countdown{
+			Scenario:   scenarioNotification,
+			Factor:     factor,
+			FireAt:     env.NotificationTimestamps[i],
+			Namespaces: env.Namespaces,
+			Callback:   makeNotifyCallback(client, env, factor),
+		})
+	}
+	if len(missed) > 0 && env.RemainingTtl > 0 {
+		factor := slices.Max(missed)
+		logrus.Infof("Env '%s' missed notifications %v, delivering factor %v", env.Name, missed, factor)
+		// Remembered before the callback runs, so a resync in between does not deliver it again
+		rememberNotified(env, factor)
+		go makeNotifyCallback(client, env, factor)(env.Namespaces)
+	}
+	return countdowns
+}
+
+func makeNotifyCallback(client kubernetes.Interface, env Env, factor float64) CountdownCallback {
+	return func(namespaces []string) {
+		status := rememberNotified(env, factor)
+		recordEnvExpiring(env)
+		emitEnvWarning(env, factor)
+		notification := newNotification(env, notify.KindExpiring)
+		notification.Factor = factor
+		deliverNotification(env, notification, func() {
+			if err := recordNotified(client, env, status); err != nil {
+				logrus.Errorf("Failed to record notification of env '%s': %v", env.Name, err)
+			}
+		})
+	}
+}
+
+// Sink names accepted in kelm.riftonix.io/notify, chat flavors are aliases of the chat sink
+var notifyRouteSinks = map[string]string{
+	"slack":                 notify.SinkChat,
+	"mattermost":            notify.SinkChat,
+	notify.SinkChat:         notify.SinkChat,
+	notify.SinkEmail:        notify.SinkEmail,
+	notify.SinkWebhook:      notify.SinkWebhook,
+	notify.SinkAlertmanager: notify.SinkAlertmanager,
+}
+
+// parseNotifyRoutes parses "slack:#team-a,email:owner,webhook" into routes like "chat:#team-a".
+// Email targets are "owner" for kelm.riftonix.io/owner.email or an address.
+func parseNotifyRoutes(s string) ([]string, error) {
+	var routes []string
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, target, _ := strings.Cut(entry, ":")
+		sink, ok := notifyRouteSinks[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return nil, fmt.Errorf("unknown sink %q", name)
+		}
+		target = strings.TrimSpace(target)
+		switch {
+		case sink == notify.SinkEmail && (target == "" || target == "owner"):
+			target = "owner"
+		case sink == notify.SinkEmail:
+			address, err := mail.ParseAddress(target)
+			if err != nil {
+				return nil, err
+			}
+			target = address.Address
+		case sink != notify.SinkChat && target != "":
+			return nil, fmt.Errorf("sink %q takes no target", name)
+		}
+		route := sink
+		if target != "" {
+			route += ":" + target
+		}
+		if !slices.Contains(routes, route) {
+			routes = append(routes, route)
+		}
+	}
+	return routes, nil
+}
+
+// applyNotifyRoutes limits the notification to the env sinks and adds their channels and recipients
+func applyNotifyRoutes(notification *notify.Notification, env Env) {
+	var owners []string
+	for _, route := range env.NotifyRoutes {
+		sink, target, _ := strings.Cut(route, ":")
+		if !slices.Contains(notification.Sinks, sink) {
+			notification.Sinks = append(notification.Sinks, sink)
+		}
+		switch {
+		case sink == notify.SinkChat && target != "" && !slices.Contains(notification.Channels, target):
+			notification.Channels = append(notification.Channels, target)
+		case sink == notify.SinkEmail && target == "owner":
+			for _, owner := range env.OwnerEmails {
+				if !slices.Contains(owners, owner) {
+					owners = append(owners, owner)
+				}
+			}
+		case sink == notify.SinkEmail && !slices.Contains(owners, target):
+			owners = append(owners, target)
+		}
+	}
+	notification.Owners = owners
+}
+
+func parseNotifiedStatus(ns core.Namespace) notifiedStatus {
+	var status notifiedStatus
+	s := ns.Annotations["kelm.riftonix.io/status.notified"]
+	if s == "" {
+		return status
+	}
+	// Status is written by kelm, a broken one only means notifications may repeat
+	if err := json.Unmarshal([]byte(s), &status); err != nil {
+		logrus.Warnf("Ignoring namespace %s annotation kelm.riftonix.io/status.notified '%s': %v", ns.Name, s, err)
+		return notifiedStatus{}
+	}
+	status.Anchor, status.Warned = status.Anchor.UTC(), status.Warned.UTC()
+	return status
+}
+
+// mergeFactors returns the sorted union of factors
+func mergeFactors(factors []float64, more []float64) []float64 {
+	merged := slices.Clone(factors)
+	for _, factor := range more {
+		if !slices.Contains(merged, factor) {
+			merged = append(merged, factor)
+		}
+	}
+	slices.Sort(merged)
+	return merged
+}
+
+// getNotifiedFactors returns factors delivered for the current env anchor and deadline, persisted or just sent
+func getNotifiedFactors(env Env) []float64 {
+	notifiedCacheMu.Lock()
+	defer notifiedCacheMu.Unlock()
+	factors := env.NotifiedFactors
+	if status, ok := notifiedCache[env.Name]; ok {
+		if status.Anchor.Equal(env.AnchorTimestamp) {
+			factors = mergeFactors(factors, status.Factors)
+		} else {
+			// The env was extended, its markers start over
+			delete(notifiedCache, env.Name)
+		}
+	}
+	return dropRescheduledFactors(env, factors)
+}
+
+// dropRescheduledFactors drops markers of factors whose time is in the future again.
+// A delivered factor is due at the latest when it fires, so only a later deadline moves it past now,
+// such as a raised expiresAt, an ended hold or a later schedule match, which keep the anchor.
+func dropRescheduledFactors(env Env, factors []float64) []float64 {
+	now := time.Now()
+	return slices.DeleteFunc(slices.Clone(factors), func(factor float64) bool {
+		i := slices.Index(env.NotificationFactors, factor)
+		return i >= 0 && i < len(env.NotificationTimestamps) && env.NotificationTimestamps[i].After(now)
+	})
+}
+
+// rememberNotified marks the factor and every factor whose time has passed as delivered,
+// and the env as warned about its current deadline
+func rememberNotified(env Env, factor float64) notifiedStatus {
+	factors := []float64{factor}
+	for i, passedFactor := range env.NotificationFactors {
+		if !env.NotificationTimestamps[i].After(time.Now()) {
+			factors = append(factors, passedFactor)
+		}
+	}
+	factors = mergeFactors(getNotifiedFactors(env), factors)
+	status := notifiedStatus{Anchor: env.AnchorTimestamp, Factors: factors, Warned: env.ExpiresAt}
+	notifiedCacheMu.Lock()
+	defer notifiedCacheMu.Unlock()
+	notifiedCache[env.Name] = status
+	return status
+}
+
+// recordNotified stores delivered factors on the env namespaces, so restarts do not repeat them
+func recordNotified(client kubernetes.Interface, env Env, status notifiedStatus) error {
+	value, err := json.Marshal(status)
+	if err != nil {
+		return fmt.Errorf("encode notification status of env '%s': %w", env.Name, err)
+	}
+	patch := fmt.Sprintf(`{"metadata":{"annotations":{"kelm.riftonix.io/status.notified":%q}}}`, value)
+	var errs []error
+	for _, ns := range env.Namespaces {
+		_, err := client.CoreV1().Namespaces().Patch(context.Background(), ns, types.MergePatchType, []byte(patch), meta.PatchOptions{})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("record notification status on namespace %s: %w", ns, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// getWarnedDeadline returns the deadline the env was last warned about, zero if the warning was resolved.
+// A status this operator delivered is newer than the persisted ones.
+func getWarnedDeadline(env Env) time.Time {
+	notifiedCacheMu.Lock()
+	defer notifiedCacheMu.Unlock()
+	if status, ok := notifiedCache[env.Name]; ok {
+		return status.Warned
+	}
+	return env.WarnedDeadline
+}
+
+// resolveWarning clears the warned deadline and keeps the factors delivered for the current anchor
+func resolveWarning(env Env) notifiedStatus {
+	status := notifiedStatus{Anchor: env.AnchorTimestamp, Factors: getNotifiedFactors(env)}
+	notifiedCacheMu.Lock()
+	defer notifiedCacheMu.Unlock()
+	notifiedCache[env.Name] = status
+	return status
+}
+
+// forgetEnvNotifications drops the notification state of a deleted env
+func forgetEnvNotifications(envName string) {
+	notifiedCacheMu.Lock()
+	delete(notifiedCache, envName)
+	notifiedCacheMu.Unlock()
+	// The namespaces are gone, so the dropped notifications are not recorded
+	dropPendingNotification(envName)
+}
+
+// notifyIfExtended sends KindExtended when a warned env got a later deadline.
+// The warning comes from kelm.riftonix.io/status.notified, so it is resolved after restarts too.
+// A resolution whose write failed is written again without sending KindExtended twice.
+func notifyIfExtended(client kubernetes.Interface, env Env) error {
+	warnedAt := getWarnedDeadline(env)
+	if warnedAt.IsZero() && !env.WarnedDeadline.IsZero() && env.ExpiresAt.After(env.WarnedDeadline) {
+		return recordNotified(client, env, resolveWarning(env))
+	}
+	if warnedAt.IsZero() || !env.ExpiresAt.After(warnedAt) {
+		return nil
+	}
+	logrus.Infof("Env '%s' was extended from %s to %s", env.Name, warnedAt.Format(time.RFC3339), env.ExpiresAt.Format(time.RFC3339))
+	status := resolveWarning(env)
+	// Recorded after the dropped held warnings, so the annotation ends resolved.
+	// A failed write is retried by the next reconcile from the persisted warning.
+	go deliverNotification(env, newNotification(env, notify.KindExtended), func() {
+		if err := recordNotified(client, env, status); err != nil {
+			logrus.Errorf("Failed to record resolved warning of env '%s': %v", env.Name, err)
+		}
+	})
+	return nil
+}
+
+func newNotification(env Env, kind string) notify.Notification {
+	notification := notify.Notification{
+		Kind:       kind,
+		Env:        env.Name,
+		Namespaces: env.Namespaces,
+		ExpiresAt:  env.ExpiresAt,
+		Channels:   slices.Clone(env.NotifyChannels),
+		Owners:     env.OwnerEmails,
+		EnvDetails: env,
+	}
+	if len(env.NotifyRoutes) > 0 {
+		applyNotifyRoutes(&notification, env)
+	}
+	return notification
+}
+
+func newNamespaceReports(results []k8s.NamespaceDeleteResult) []notify.NamespaceReport {
+	reports := make([]notify.NamespaceReport, 0, len(results))
+	for _, result := range results {
+		report := notify.NamespaceReport{
+			Namespace: result.Namespace,
+			State:     result.State,
+			Duration:  result.Duration.Round(time.Millisecond).String(),
+		}
+		if err := getDeleteResultError(result); err != nil {
+			report.Error = err.Error()
+		}
+		reports = append(reports, report)
+	}
+	return reports
+}
+
+// sendTeardownReport tells the env owners whether the env is gone or stuck until the retry
+func sendTeardownReport(env Env, kind string, report notify.TeardownReport) {
+	notification := newNotification(env, kind)
+	notification.Report = &report
+	sendNotification(notification)
+}
+
+func sendNotification(notification notify.Notification) {
+	if notifier == nil {
+		return
+	}
+	if err := notifier.Notify(context.Background(), notification); err != nil {
+		logrus.Errorf("Failed to send %s notification for env '%s': %v", notification.Kind, notification.Env, err)
+		return
+	}
+	logrus.Infof("Sent %s notification for env '%s'", notification.Kind, notification.Env)
+}
@@ -0,0 +1,516 @@
+package kelm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"kelm/internal/pkg/k8s"
+	"kelm/internal/pkg/notify"
+
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+type fakeNotifier struct {
+	mu            sync.Mutex
+	notifications []notify.Notification
+	sent          chan struct{}
+}
+
+func newFakeNotifier() *fakeNotifier {
+	return &fakeNotifier{sent: make(chan struct{}, 10)}
+}
+
+func (f *fakeNotifier) Notify(ctx context.Context, notification notify.Notification) error {
+	f.mu.Lock()
+	f.notifications = append(f.notifications, notification)
+	f.mu.Unlock()
+	f.sent <- struct{}{}
+	return nil
+}
+
+func (f *fakeNotifier) received() []notify.Notification {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]notify.Notification(nil), f.notifications...)
+}
+
+func useNotifier(t *testing.T, n notify.Notifier) {
+	previous := notifier
+	notifier = n
+	t.Cleanup(func() { notifier = previous })
+}
+
+func TestGetNotifier(t *testing.T) {
+	t.Setenv("NOTIFY_WEBHOOK_URL", "")
+	if getNotifier() != nil {
+		t.Error("Expected no notifier without NOTIFY_WEBHOOK_URL")
+	}
+	t.Setenv("NOTIFY_WEBHOOK_URL", "http://example.invalid/hook")
+	t.Setenv("NOTIFY_RETRIES", "5")
+	webhook, ok := getNotifier().(*notify.Webhook)
+	if !ok || webhook.Retries != 5 || webhook.Client.Timeout != 10*time.Second {
+		t.Errorf("Unexpected notifier: %+v", webhook)
+	}
+	t.Setenv("NOTIFY_CHAT_WEBHOOK_URL", "http://example.invalid/chat")
+	t.Setenv("NOTIFY_CHAT_FLAVOR", "teams")
+	notifiers, ok := getNotifier().(notify.Notifiers)
+	if !ok || len(notifiers) != 2 {
+		t.Fatalf("Expected webhook and chat notifiers, got %+v", notifiers)
+	}
+	if chat, ok := notifiers[1].(*notify.Chat); !ok || chat.Flavor != notify.FlavorSlack {
+		t.Errorf("Expected slack chat notifier for invalid flavor, got %+v", notifiers[1])
+	}
+	t.Setenv("NOTIFY_SMTP_ADDR", "smtp.example.com:587")
+	t.Setenv("NOTIFY_SMTP_FROM", "")
+	if notifiers, _ := getNotifier().(notify.Notifiers); len(notifiers) != 2 {
+		t.Errorf("Expected email notifier to need NOTIFY_SMTP_FROM, got %+v", notifiers)
+	}
+	t.Setenv("NOTIFY_SMTP_FROM", "kelm@example.com")
+	t.Setenv("NOTIFY_SMTP_STARTTLS", "false")
+	notifiers, _ = getNotifier().(notify.Notifiers)
+	if len(notifiers) != 3 {
+		t.Fatalf("Expected webhook, chat and email notifiers, got %+v", notifiers)
+	}
+	if email, ok := notifiers[2].(*notify.Email); !ok || email.StartTLS || email.From != "kelm@example.com" {
+		t.Errorf("Unexpected email notifier: %+v", notifiers[2])
+	}
+	t.Setenv("NOTIFY_RETRIES", "-1")
+	if retries := getNotifyRetries(); retries != defaultNotifyRetries {
+		t.Errorf("Expected default retries for invalid value, got %d", retries)
+	}
+}
+
+func TestGetNotificationCountdowns(t *testing.T) {
+	testNotifier := newFakeNotifier()
+	useNotifier(t, testNotifier)
+	forgetEnvNotifications("env1")
+	t.Cleanup(func() { forgetEnvNotifications("env1") })
+	client := fake.NewSimpleClientset(&core.Namespace{ObjectMeta: meta.ObjectMeta{Name: "ns1"}})
+	expiresAt := time.Now().Add(time.Hour).UTC()
+	anchor := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	env := Env{
+		Name:                   "env1",
+		Namespaces:             []string{"ns1"},
+		AnchorTimestamp:        anchor,
+		ExpiresAt:              expiresAt,
+		RemainingTtl:           time.Hour,
+		NotificationFactors:    []float64{0.3, 0.5, 0.9},
+		NotificationTimestamps: []time.Time{time.Now().Add(-2 * time.Minute), time.Now().Add(-time.Minute), time.Now().Add(2 * time.Second)},
+		NotifyChannels:         []string{"#team-a"},
+	}
+	scheduler := NewScheduler()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go scheduler.Run(ctx)
+	scheduler.SyncEnv(env.Name, getNotificationCountdowns(client, env))
+	if countdowns := scheduler.Countdowns(); len(countdowns) != 1 || countdowns[0].Factor != 0.9 {
+		t.Fatalf("Expected only the upcoming notification to be scheduled, got %+v", countdowns)
+	}
+	// A resync right away must not deliver the missed notification again
+	scheduler.SyncEnv(env.Name, getNotificationCountdowns(client, env))
+
+	for range 2 {
+		select {
+		case <-testNotifier.sent:
+		case <-time.After(4 * time.Second):
+			t.Fatal("Expected notification to be sent")
+		}
+	}
+	received := testNotifier.received()
+	if len(received) != 2 || received[0].Factor != 0.5 || received[1].Factor != 0.9 {
+		t.Fatalf("Expected missed 0.5 once and then 0.9, got %+v", received)
+	}
+	if received[1].Kind != notify.KindExpiring || received[1].Channels[0] != "#team-a" || !received[1].ExpiresAt.Equal(expiresAt) {
+		t.Errorf("Unexpected notification: %+v", received[1])
+	}
+
+	ns, err := client.CoreV1().Namespaces().Get(context.Background(), "ns1", meta.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get namespace: %v", err)
+	}
+	status := parseNotifiedStatus(*ns)
+	if !status.Anchor.Equal(anchor) || !slices.Equal(status.Factors, []float64{0.3, 0.5, 0.9}) {
+		t.Errorf("Unexpected kelm.riftonix.io/status.notified %q", ns.Annotations["kelm.riftonix.io/status.notified"])
+	}
+}
+
+func TestGetNotificationCountdownsNotified(t *testing.T) {
+	testNotifier := newFakeNotifier()
+	useNotifier(t, testNotifier)
+	forgetEnvNotifications("env1")
+	env := Env{
+		Name:                   "env1",
+		Namespaces:             []string{"ns1"},
+		RemainingTtl:           time.Hour,
+		NotificationFactors:    []float64{0.5, 0.9},
+		NotificationTimestamps: []time.Time{time.Now().Add(-time.Minute), time.Now().Add(-time.Second)},
+		NotifiedFactors:        []float64{0.5, 0.9},
+	}
+	if countdowns := getNotificationCountdowns(fake.NewSimpleClientset(), env); len(countdowns) != 0 {
+		t.Errorf("Expected no countdowns for delivered factors, got %d", len(countdowns))
+	}
+	select {
+	case <-testNotifier.sent:
+		t.Error("Expected no notification for delivered factors")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestGetNotificationCountdownsAfterExtension(t *testing.T) {
+	useNotifier(t, newFakeNotifier())
+	created := time.Now().Add(-90 * time.Minute).UTC().Truncate(time.Second)
+	getEnv := func(t *testing.T, ns *core.Namespace) Env {
+		envs, err := getEnvs(newNamespaceLister(ns), labels.Set{"kelm.riftonix.io/managed": "true"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		return envs["env1"]
+	}
+	// 0.5 of the 2h lifetime passed 30m ago and was delivered
+	notified := func(t *testing.T, ns *core.Namespace) {
+		ns.Annotations["kelm.riftonix.io/status.notified"] = fmt.Sprintf(`{"anchor":%q,"factors":[0.5]}`, getEnv(t, ns).AnchorTimestamp.Format(time.RFC3339))
+		if countdowns := getNotificationCountdowns(fake.NewSimpleClientset(), getEnv(t, ns)); len(countdowns) != 0 {
+			t.Fatalf("Expected delivered factor to stay marked, got %+v", countdowns)
+		}
+	}
+	tests := []struct {
+		name   string
+		extend func(ns *core.Namespace)
+	}{
+		{
+			name: "raised expiresAt",
+			extend: func(ns *core.Namespace) {
+				ns.Annotations["kelm.riftonix.io/expiresAt"] = created.Add(6 * time.Hour).Format(time.RFC3339)
+			},
+		},
+		{
+			name: "released hold",
+			extend: func(ns *core.Namespace) {
+				// The hold froze the countdown for 80m and was removed
+				ns.Annotations["kelm.riftonix.io/hold.since"] = created.Add(10 * time.Minute).Format(time.RFC3339)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forgetEnvNotifications("env1")
+			t.Cleanup(func() { forgetEnvNotifications("env1") })
+			ns := makeNamespace("ns1", "env1", "", "0", `[0.5]`, "", created, "true")
+			ns.Annotations["kelm.riftonix.io/expiresAt"] = created.Add(2 * time.Hour).Format(time.RFC3339)
+			notified(t, ns)
+
+			tt.extend(ns)
+			env := getEnv(t, ns)
+			countdowns := getNotificationCountdowns(fake.NewSimpleClientset(), env)
+			if len(countdowns) != 1 || countdowns[0].Factor != 0.5 || !countdowns[0].FireAt.After(time.Now()) {
+				t.Errorf("Expected 0.5 to be scheduled again for deadline %v, got %+v", env.ExpiresAt, countdowns)
+			}
+		})
+	}
+}
+
+func TestGetNotificationCountdownsDisabled(t *testing.T) {
+	useNotifier(t, nil)
+	env := Env{
+		Name:                   "env1",
+		NotificationFactors:    []float64{0.5},
+		NotificationTimestamps: []time.Time{time.Now().Add(time.Hour)},
+	}
+	if countdowns := getNotificationCountdowns(fake.NewSimpleClientset(), env); len(countdowns) != 0 {
+		t.Errorf("Expected no countdowns without notifier, got %d", len(countdowns))
+	}
+}
+
+func TestGetNotifiedFactors(t *testing.T) {
+	forgetEnvNotifications("env1")
+	t.Cleanup(func() { forgetEnvNotifications("env1") })
+	anchor := time.Now().UTC()
+	env := Env{
+		Name:                   "env1",
+		AnchorTimestamp:        anchor,
+		NotificationFactors:    []float64{0.5, 0.9},
+		NotificationTimestamps: []time.Time{time.Now().Add(-2 * time.Hour), time.Now().Add(-time.Hour)},
+		NotifiedFactors:        []float64{0.9},
+	}
+	rememberNotified(env, 0.5)
+	if factors := getNotifiedFactors(env); !slices.Equal(factors, []float64{0.5, 0.9}) {
+		t.Errorf("Expected remembered and persisted factors, got %v", factors)
+	}
+	// A later deadline with the same anchor moves 0.9 into the future
+	env.NotificationTimestamps[1] = time.Now().Add(time.Hour)
+	if factors := getNotifiedFactors(env); !slices.Equal(factors, []float64{0.5}) {
+		t.Errorf("Expected marker of the rescheduled factor to be dropped, got %v", factors)
+	}
+	env.AnchorTimestamp = anchor.Add(time.Hour)
+	env.NotifiedFactors = nil
+	if factors := getNotifiedFactors(env); len(factors) != 0 {
+		t.Errorf("Expected markers to reset after extension, got %v", factors)
+	}
+}
+
+func TestNotifyIfExtended(t *testing.T) {
+	notifier := newFakeNotifier()
+	useNotifier(t, notifier)
+	forgetEnvNotifications("env1")
+	t.Cleanup(func() { forgetEnvNotifications("env1") })
+	client := fake.NewSimpleClientset(&core.Namespace{ObjectMeta: meta.ObjectMeta{Name: "ns1"}})
+	deadline := time.Now().Add(time.Hour).UTC()
+	env := Env{Name: "env1", Namespaces: []string{"ns1"}, ExpiresAt: deadline,
+		NotificationFactors: []float64{0.5}, NotificationTimestamps: []time.Time{time.Now()}}
+
+	notifyIfExtended(client, env)
+	rememberNotified(env, 0.5)
+	notifyIfExtended(client, env)
+	select {
+	case <-notifier.sent:
+		t.Fatal("Expected no notification without a later deadline")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	env.ExpiresAt = deadline.Add(time.Hour)
+	notifyIfExtended(client, env)
+	select {
+	case <-notifier.sent:
+	case <-time.After(time.Second):
+		t.Fatal("Expected extended notification")
+	}
+	if received := notifier.received(); len(received) != 1 || received[0].Kind != notify.KindExtended {
+		t.Errorf("Unexpected notifications: %+v", received)
+	}
+	// Recorded once the extended notification is sent
+	expectNotifiedStatus(t, client, "ns1", func(status notifiedStatus) bool {
+		return status.Warned.IsZero() && slices.Equal(status.Factors, []float64{0.5})
+	})
+
+	// The warning is consumed, the next extension needs a new one
+	env.ExpiresAt = deadline.Add(2 * time.Hour)
+	notifyIfExtended(client, env)
+	select {
+	case <-notifier.sent:
+		t.Error("Expected a single extended notification per warning")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// expectNotifiedStatus waits until the namespace status.notified matches
+func expectNotifiedStatus(t *testing.T, client *fake.Clientset, name string, matches func(notifiedStatus) bool) {
+	t.Helper()
+	var status notifiedStatus
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		ns, err := client.CoreV1().Namespaces().Get(context.Background(), name, meta.GetOptions{})
+		if err != nil {
+			t.Fatalf("Expected namespace, got %v", err)
+		}
+		if status = parseNotifiedStatus(*ns); matches(status) {
+			return
+		}
+	}
+	t.Errorf("Unexpected notification status on namespace %s: %+v", name, status)
+}
+
+func TestNotifyIfExtendedDropsHeldWarning(t *testing.T) {
+	notifier := newFakeNotifier()
+	useNotifier(t, notifier)
+	setQuietNow(t)
+	forgetEnvNotifications("env1")
+	t.Cleanup(func() { forgetEnvNotifications("env1") })
+	client := fake.NewSimpleClientset(&core.Namespace{ObjectMeta: meta.ObjectMeta{Name: "ns1"}})
+	var patches []notifiedStatus
+	var patchesMu sync.Mutex
+	client.PrependReactor("patch", "namespaces", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		var patch struct {
+			Metadata struct {
+				Annotations map[string]string `json:"annotations"`
+			} `json:"metadata"`
+		}
+		_ = json.Unmarshal(action.(k8stesting.PatchAction).GetPatch(), &patch)
+		var status notifiedStatus
+		_ = json.Unmarshal([]byte(patch.Metadata.Annotations["kelm.riftonix.io/status.notified"]), &status)
+		patchesMu.Lock()
+		patches = append(patches, status)
+		patchesMu.Unlock()
+		return false, nil, nil
+	})
+	anchor := time.Now().Add(-time.Hour).UTC()
+	deadline := time.Now().Add(24 * time.Hour).UTC()
+	env := Env{Name: "env1", Namespaces: []string{"ns1"}, AnchorTimestamp: anchor, ExpiresAt: deadline,
+		NotificationFactors: []float64{0.5}, NotificationTimestamps: []time.Time{time.Now()}}
+
+	// Held by quiet hours, then the env is extended
+	makeNotifyCallback(client, env, 0.5)(env.Namespaces)
+	env.ExpiresAt = deadline.Add(time.Hour)
+	if err := notifyIfExtended(client, env); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	select {
+	case <-notifier.sent:
+	case <-time.After(time.Second):
+		t.Fatal("Expected extended notification")
+	}
+	if received := notifier.received(); len(received) != 1 || received[0].Kind != notify.KindExtended {
+		t.Errorf("Expected only the extended notification, got %+v", received)
+	}
+
+	// The dropped warning counts as delivered and the resolution is written last
+	expectNotifiedStatus(t, client, "ns1", func(status notifiedStatus) bool {
+		return status.Warned.IsZero() && slices.Equal(status.Factors, []float64{0.5})
+	})
+	patchesMu.Lock()
+	defer patchesMu.Unlock()
+	if len(patches) != 2 || !patches[0].Warned.Equal(deadline) || !patches[1].Warned.IsZero() {
+		t.Errorf("Expected the dropped warning and then the resolution, got %+v", patches)
+	}
+}
+
+func TestNotifyIfExtendedRecordFailure(t *testing.T) {
+	notifier := newFakeNotifier()
+	useNotifier(t, notifier)
+	forgetEnvNotifications("env1")
+	t.Cleanup(func() { forgetEnvNotifications("env1") })
+	client := fake.NewSimpleClientset(&core.Namespace{ObjectMeta: meta.ObjectMeta{Name: "ns1"}})
+	var failed atomic.Bool
+	client.PrependReactor("patch", "namespaces", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if failed.Swap(true) {
+			return false, nil, nil
+		}
+		return true, nil, errors.New("apiserver unavailable")
+	})
+	deadline := time.Now().Add(time.Hour).UTC()
+	env := Env{Name: "env1", Namespaces: []string{"ns1"}, ExpiresAt: deadline.Add(time.Hour), WarnedDeadline: deadline}
+
+	// The resolution is written after sending, its failure is logged
+	if err := notifyIfExtended(client, env); err != nil {
+		t.Fatalf("Expected no error before sending, got %v", err)
+	}
+	for deadline := time.Now().Add(time.Second); !failed.Load() && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+	// The next reconcile still sees the persisted warning, it writes the resolution without a second notification
+	if err := notifyIfExtended(client, env); err != nil {
+		t.Fatalf("Expected the retry to record the resolution, got %v", err)
+	}
+	ns, err := client.CoreV1().Namespaces().Get(context.Background(), "ns1", meta.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected namespace, got %v", err)
+	}
+	if status, ok := ns.Annotations["kelm.riftonix.io/status.notified"]; !ok || !parseNotifiedStatus(*ns).Warned.IsZero() {
+		t.Errorf("Expected resolved warning to be recorded, got %q", status)
+	}
+	select {
+	case <-notifier.sent:
+	case <-time.After(time.Second):
+		t.Fatal("Expected extended notification")
+	}
+	select {
+	case <-notifier.sent:
+		t.Error("Expected a single extended notification")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestNotifyIfExtendedAfterRestart(t *testing.T) {
+	notifier := newFakeNotifier()
+	useNotifier(t, notifier)
+	forgetEnvNotifications("env1")
+	t.Cleanup(func() { forgetEnvNotifications("env1") })
+	client := fake.NewSimpleClientset(&core.Namespace{ObjectMeta: meta.ObjectMeta{Name: "ns1"}})
+	deadline := time.Now().Add(time.Hour).UTC()
+	// Nothing in memory, the warning comes from kelm.riftonix.io/status.notified
+	env := Env{Name: "env1", Namespaces: []string{"ns1"}, ExpiresAt: deadline.Add(time.Hour), WarnedDeadline: deadline}
+
+	notifyIfExtended(client, env)
+	select {
+	case <-notifier.sent:
+	case <-time.After(time.Second):
+		t.Fatal("Expected extended notification for a persisted warning")
+	}
+	if received := notifier.received(); len(received) != 1 || received[0].Kind != notify.KindExtended {
+		t.Errorf("Unexpected notifications: %+v", received)
+	}
+}
+
+func TestParseNotifyRoutes(t *testing.T) {
+	tests := []struct {
+		spec        string
+		expected    []string
+		expectError bool
+	}{
+		{"", nil, false},
+		{"slack:#team-a, email:owner", []string{"chat:#team-a", "email:owner"}, false},
+		{"mattermost:town-square,chat,webhook,alertmanager", []string{"chat:town-square", "chat", "webhook", "alertmanager"}, false},
+		{"email,email:owner,email:Bob <bob@example.com>", []string{"email:owner", "email:bob@example.com"}, false},
+		{"sms:+100", nil, true},
+		{"email:bob", nil, true},
+		{"webhook:https://example.com", nil, true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.spec, func(t *testing.T) {
+			routes, err := parseNotifyRoutes(testCase.spec)
+			if testCase.expectError != (err != nil) {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			if !slices.Equal(routes, testCase.expected) {
+				t.Errorf("Expected %v, got %v", testCase.expected, routes)
+			}
+		})
+	}
+}
+
+func TestNewNotificationRoutes(t *testing.T) {
+	env := Env{
+		Name:           "env1",
+		NotifyChannels: []string{"#general"},
+		OwnerEmails:    []string{"alice@example.com"},
+	}
+	if notification := newNotification(env, notify.KindExpiring); len(notification.Sinks) != 0 || len(notification.Owners) != 1 {
+		t.Errorf("Expected every sink without routes, got %+v", notification)
+	}
+	env.NotifyRoutes = []string{"chat:#team-a", "email:owner", "email:bob@example.com"}
+	notification := newNotification(env, notify.KindExpiring)
+	if !slices.Equal(notification.Sinks, []string{notify.SinkChat, notify.SinkEmail}) {
+		t.Errorf("Unexpected sinks %v", notification.Sinks)
+	}
+	if !slices.Equal(notification.Channels, []string{"#general", "#team-a"}) || !slices.Equal(env.NotifyChannels, []string{"#general"}) {
+		t.Errorf("Unexpected channels %v", notification.Channels)
+	}
+	if !slices.Equal(notification.Owners, []string{"alice@example.com", "bob@example.com"}) {
+		t.Errorf("Unexpected recipients %v", notification.Owners)
+	}
+	env.NotifyRoutes = []string{"email:bob@example.com"}
+	if notification := newNotification(env, notify.KindExpiring); !slices.Equal(notification.Owners, []string{"bob@example.com"}) {
+		t.Errorf("Expected owners only when routed, got %v", notification.Owners)
+	}
+}
+
+func TestSendTeardownReport(t *testing.T) {
+	testNotifier := newFakeNotifier()
+	useNotifier(t, testNotifier)
+	env := Env{Name: "env1", Namespaces: []string{"ns1", "ns2"}}
+	results := []k8s.NamespaceDeleteResult{
+		{Namespace: "ns1", State: "deleted", Duration: 1500 * time.Millisecond},
+		{Namespace: "ns2", State: "timeout", Duration: time.Minute, DeletionError: errors.New("deleting"), FinalizerError: errors.New("forbidden")},
+	}
+	report := notify.TeardownReport{Namespaces: newNamespaceReports(results), RetryAt: time.Now().Add(time.Minute)}
+	if report.Namespaces[0].Duration != "1.5s" || report.Namespaces[0].Error != "" || report.Namespaces[1].Error != "forbidden" {
+		t.Errorf("Unexpected namespace reports: %+v", report.Namespaces)
+	}
+
+	sendTeardownReport(env, notify.KindDeletionFailed, report)
+	received := testNotifier.received()
+	if len(received) != 1 || received[0].Kind != notify.KindDeletionFailed || received[0].Report == nil || len(received[0].Report.Namespaces) != 2 {
+		t.Errorf("Expected deletion_failed notification with report, got %+v", received)
+	}
+}
@@ -0,0 +1,85 @@
+package kelm
+
+import (
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/util/workqueue"
+)
+
+const defaultReconcileWorkers = 4
+
+// Env names waiting for reconcile, nil until the namespace watch starts
+var reconcileQueue workqueue.TypedRateLimitingInterface[string]
+var reconcileQueueMu sync.RWMutex
+
+func getReconcileWorkers() int {
+	s := os.Getenv("RECONCILE_WORKERS")
+	if s == "" {
+		return defaultReconcileWorkers
+	}
+	workers, err := strconv.Atoi(s)
+	if err != nil || workers < 1 {
+		logrus.Warnf("Invalid RECONCILE_WORKERS %q, using %d", s, defaultReconcileWorkers)
+		return defaultReconcileWorkers
+	}
+	return workers
+}
+
+// newReconcileQueue returns a queue that merges pending requests for the same env
+// and hands an env to one worker at a time. Failed envs are retried with exponential backoff.
+func newReconcileQueue() workqueue.TypedRateLimitingInterface[string] {
+	return workqueue.NewTypedRateLimitingQueueWithConfig(
+		workqueue.DefaultTypedControllerRateLimiter[string](),
+		workqueue.TypedRateLimitingQueueConfig[string]{Name: "envs"},
+	)
+}
+
+// setReconcileQueue publishes the queue of the running watch, nil when it stops
+func setReconcileQueue(queue workqueue.TypedRateLimitingInterface[string]) {
+	reconcileQueueMu.Lock()
+	defer reconcileQueueMu.Unlock()
+	reconcileQueue = queue
+}
+
+func getReconcileQueue() workqueue.TypedRateLimitingInterface[string] {
+	reconcileQueueMu.RLock()
+	defer reconcileQueueMu.RUnlock()
+	return reconcileQueue
+}
+
+func enqueueEnv(envName string) {
+	if queue := getReconcileQueue(); queue != nil {
+		queue.Add(envName)
+	}
+}
+
+func enqueueEnvAfter(envName string, delay time.Duration) {
+	if queue := getReconcileQueue(); queue != nil {
+		queue.AddAfter(envName, delay)
+	}
+}
+
+// runReconcileWorker reconciles envs from the queue until the queue is shut down
+func runReconcileWorker(queue workqueue.TypedRateLimitingInterface[string], reconcile func(envName string) error) {
+	for processNextEnv(queue, reconcile) {
+	}
+}
+
+func processNextEnv(queue workqueue.TypedRateLimitingInterface[string], reconcile func(envName string) error) bool {
+	envName, shutdown := queue.Get()
+	if shutdown {
+		return false
+	}
+	defer queue.Done(envName)
+	if err := reconcile(envName); err != nil {
+		logrus.Errorf("Failed to reconcile env '%s', retry %d: %v", envName, queue.NumRequeues(envName)+1, err)
+		queue.AddRateLimited(envName)
+		return true
+	}
+	queue.Forget(envName)
+	return true
+}
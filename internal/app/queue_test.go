@@ -0,0 +1,90 @@
+package kelm
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestGetReconcileWorkers(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected int
+	}{
+		{"", defaultReconcileWorkers},
+		{"8", 8},
+		{"0", defaultReconcileWorkers},
+		{"many", defaultReconcileWorkers},
+	}
+	for _, testCase := range tests {
+		t.Setenv("RECONCILE_WORKERS", testCase.value)
+		if workers := getReconcileWorkers(); workers != testCase.expected {
+			t.Errorf("RECONCILE_WORKERS=%q: expected %d, got %d", testCase.value, testCase.expected, workers)
+		}
+	}
+}
+
+func TestProcessNextEnv(t *testing.T) {
+	queue := newReconcileQueue()
+	defer queue.ShutDown()
+	queue.Add("env1")
+	queue.Add("env1")
+	if queue.Len() != 1 {
+		t.Fatalf("Expected pending requests for one env to merge, got %d", queue.Len())
+	}
+
+	failures := 1
+	var reconciled []string
+	reconcile := func(envName string) error {
+		reconciled = append(reconciled, envName)
+		if failures > 0 {
+			failures--
+			return errors.New("cache is not ready")
+		}
+		return nil
+	}
+	if !processNextEnv(queue, reconcile) || queue.NumRequeues("env1") != 1 {
+		t.Fatalf("Expected failed env to be requeued, got %d requeues", queue.NumRequeues("env1"))
+	}
+	if !processNextEnv(queue, reconcile) || queue.NumRequeues("env1") != 0 {
+		t.Errorf("Expected reconciled env to be forgotten, got %d requeues", queue.NumRequeues("env1"))
+	}
+	if !slices.Equal(reconciled, []string{"env1", "env1"}) {
+		t.Errorf("Unexpected reconciles %v", reconciled)
+	}
+
+	queue.ShutDown()
+	if processNextEnv(queue, reconcile) {
+		t.Error("Expected worker to stop after queue shutdown")
+	}
+}
+
+func TestResyncCountdowns(t *testing.T) {
+	queue := newReconcileQueue()
+	defer queue.ShutDown()
+	setReconcileQueue(queue)
+	t.Cleanup(func() { setReconcileQueue(nil) })
+
+	validTime := time.Now().UTC().Format(time.RFC3339)
+	lister := newNamespaceLister(
+		makeNamespace("ns1", "env1", "1h", "0.5", "[]", validTime, time.Now(), "true"),
+		makeNamespace("ns2", "env1", "1h", "0.5", "[]", validTime, time.Now(), "true"),
+		makeNamespace("ns3", "env2", "1h", "bad", "[]", validTime, time.Now(), "true"),
+	)
+	// env3 lost its namespaces, its countdowns must be cancelled by reconcile
+	scheduler := NewScheduler()
+	scheduler.SyncEnv("env3", []Countdown{{Scenario: scenarioRemoval, FireAt: time.Now().Add(time.Hour)}})
+	resyncCountdowns(lister, scheduler)
+
+	var queued []string
+	for queue.Len() > 0 {
+		envName, _ := queue.Get()
+		queued = append(queued, envName)
+		queue.Done(envName)
+	}
+	slices.Sort(queued)
+	if !slices.Equal(queued, []string{"env1", "env2", "env3"}) {
+		t.Errorf("Expected every env once, got %v", queued)
+	}
+}
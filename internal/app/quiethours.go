@@ -0,0 +1,140 @@
+package kelm
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"kelm/internal/pkg/notify"
+	"kelm/internal/pkg/timer"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Expiring notifications of one env held back by quiet hours, sent together when the window opens
+type pendingNotification struct {
+	notifications []notify.Notification
+	onSent        []func()
+	sendAt        time.Time
+	timer         *time.Timer
+}
+
+var pendingNotifications = make(map[string]*pendingNotification)
+var pendingNotificationsMu sync.Mutex
+
+// getQuietHours returns the operator quiet hours, ok is false when they are not configured
+func getQuietHours() (timer.QuietHours, bool) {
+	s := os.Getenv("NOTIFY_QUIET_HOURS")
+	if s == "" {
+		return timer.QuietHours{}, false
+	}
+	quiet, err := timer.ParseQuietHours(s)
+	if err != nil {
+		logrus.Warnf("Invalid NOTIFY_QUIET_HOURS %q, sending notifications at any time: %v", s, err)
+		return timer.QuietHours{}, false
+	}
+	return quiet, true
+}
+
+// getQuietHoursLead returns how long before deletion held notifications are sent at the latest
+func getQuietHoursLead() time.Duration {
+	return getDurationEnv("NOTIFY_QUIET_HOURS_LEAD", 15*time.Minute)
+}
+
+// getEnvQuietHours returns quiet hours in the env timezone
+func getEnvQuietHours(env Env) (timer.QuietHours, bool) {
+	quiet, ok := getQuietHours()
+	if !ok || env.NotifyTimezone == "" {
+		return quiet, ok
+	}
+	location, err := time.LoadLocation(env.NotifyTimezone)
+	if err != nil {
+		// You should not see this log, the timezone is validated in handleNamespace
+		logrus.Warnf("Env '%s' has bad notify timezone %q: %v", env.Name, env.NotifyTimezone, err)
+		return quiet, ok
+	}
+	return quiet.In(location), ok
+}
+
+// deliverNotification sends the notification and calls onSent afterwards.
+// Expiring notifications in quiet hours are held until the window ends,
+// but no later than NOTIFY_QUIET_HOURS_LEAD before the env deadline.
+// Other kinds are sent at once and drop the held notification of the env.
+// Dropped notifications count as delivered, their onSent runs before the notification is sent.
+func deliverNotification(env Env, notification notify.Notification, onSent func()) {
+	if notification.Kind != notify.KindExpiring {
+		for _, dropped := range dropPendingNotification(env.Name) {
+			runOnSent(dropped)
+		}
+		sendNotification(notification)
+		runOnSent(onSent)
+		return
+	}
+	now := time.Now()
+	quiet, ok := getEnvQuietHours(env)
+	if !ok || !quiet.Contains(now) {
+		sendNotification(notification)
+		runOnSent(onSent)
+		return
+	}
+	sendAt := quiet.End(now)
+	if latest := env.ExpiresAt.Add(-getQuietHoursLead()); latest.Before(sendAt) {
+		sendAt = latest
+	}
+	if !sendAt.After(now) {
+		sendNotification(notification)
+		runOnSent(onSent)
+		return
+	}
+	logrus.Infof("Holding %s notification for env '%s' until %s because of quiet hours", notification.Kind, env.Name, sendAt.Format(time.RFC3339))
+	pendingNotificationsMu.Lock()
+	defer pendingNotificationsMu.Unlock()
+	if pending := pendingNotifications[env.Name]; pending != nil {
+		pending.notifications = append(pending.notifications, notification)
+		pending.onSent = append(pending.onSent, onSent)
+		// A stopped timer is already firing and sends the whole batch
+		if sendAt.Before(pending.sendAt) && pending.timer.Stop() {
+			pending.sendAt = sendAt
+			pending.timer.Reset(sendAt.Sub(now))
+		}
+		return
+	}
+	pending := &pendingNotification{notifications: []notify.Notification{notification}, onSent: []func(){onSent}, sendAt: sendAt}
+	pending.timer = time.AfterFunc(sendAt.Sub(now), func() { flushPendingNotification(env.Name, pending) })
+	pendingNotifications[env.Name] = pending
+}
+
+// flushPendingNotification sends the held notifications in the order they were delivered
+func flushPendingNotification(envName string, pending *pendingNotification) {
+	pendingNotificationsMu.Lock()
+	if pendingNotifications[envName] != pending {
+		pendingNotificationsMu.Unlock()
+		return
+	}
+	delete(pendingNotifications, envName)
+	pendingNotificationsMu.Unlock()
+	for i, notification := range pending.notifications {
+		sendNotification(notification)
+		runOnSent(pending.onSent[i])
+	}
+}
+
+func runOnSent(onSent func()) {
+	if onSent != nil {
+		onSent()
+	}
+}
+
+// dropPendingNotification cancels the held notifications of the env and returns their onSent callbacks
+func dropPendingNotification(envName string) []func() {
+	pendingNotificationsMu.Lock()
+	defer pendingNotificationsMu.Unlock()
+	pending := pendingNotifications[envName]
+	if pending == nil {
+		return nil
+	}
+	pending.timer.Stop()
+	delete(pendingNotifications, envName)
+	logrus.Infof("Dropped %d held notifications for env '%s'", len(pending.notifications), envName)
+	return pending.onSent
+}
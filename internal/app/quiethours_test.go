@@ -0,0 +1,91 @@
+package kelm
+
+import (
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"kelm/internal/pkg/notify"
+)
+
+// setQuietNow configures quiet hours around the current time
+func setQuietNow(t *testing.T) {
+	now := time.Now().UTC()
+	t.Setenv("NOTIFY_QUIET_HOURS", now.Add(-time.Hour).Format("15:04")+"-"+now.Add(time.Hour).Format("15:04")+" UTC")
+}
+
+func TestDeliverNotificationQuietHours(t *testing.T) {
+	t.Run("outside quiet hours", func(t *testing.T) {
+		testNotifier := newFakeNotifier()
+		useNotifier(t, testNotifier)
+		t.Setenv("NOTIFY_QUIET_HOURS", "")
+		env := Env{Name: "env1", ExpiresAt: time.Now().Add(time.Hour)}
+		var sent atomic.Bool
+		deliverNotification(env, newNotification(env, notify.KindExpiring), func() { sent.Store(true) })
+		if len(testNotifier.received()) != 1 || !sent.Load() {
+			t.Error("Expected notification to be sent at once")
+		}
+	})
+
+	t.Run("held until the lead before deletion", func(t *testing.T) {
+		testNotifier := newFakeNotifier()
+		useNotifier(t, testNotifier)
+		setQuietNow(t)
+		t.Setenv("NOTIFY_QUIET_HOURS_LEAD", "1s")
+		t.Cleanup(func() { dropPendingNotification("env1") })
+		env := Env{Name: "env1", ExpiresAt: time.Now().Add(3 * time.Second)}
+		var sent atomic.Int32
+		first := newNotification(env, notify.KindExpiring)
+		first.Factor = 0.5
+		deliverNotification(env, first, func() { sent.Add(1) })
+		second := newNotification(env, notify.KindExpiring)
+		second.Factor = 0.9
+		deliverNotification(env, second, func() { sent.Add(1) })
+		if len(testNotifier.received()) != 0 {
+			t.Fatal("Expected notifications to be held in quiet hours")
+		}
+		select {
+		case <-testNotifier.sent:
+		case <-time.After(4 * time.Second):
+			t.Fatal("Expected held notification before deletion")
+		}
+		time.Sleep(100 * time.Millisecond)
+		received := testNotifier.received()
+		if len(received) != 2 || received[0].Factor != 0.5 || received[1].Factor != 0.9 || sent.Load() != 2 {
+			t.Errorf("Expected the held batch in order, got %+v (sent %d)", received, sent.Load())
+		}
+	})
+
+	t.Run("extension drops held notification", func(t *testing.T) {
+		testNotifier := newFakeNotifier()
+		useNotifier(t, testNotifier)
+		setQuietNow(t)
+		env := Env{Name: "env1", ExpiresAt: time.Now().Add(24 * time.Hour)}
+		var order []string
+		deliverNotification(env, newNotification(env, notify.KindExpiring), func() { order = append(order, "expiring") })
+		deliverNotification(env, newNotification(env, notify.KindExtended), func() { order = append(order, "extended") })
+		pendingNotificationsMu.Lock()
+		_, pending := pendingNotifications["env1"]
+		pendingNotificationsMu.Unlock()
+		if received := testNotifier.received(); pending || len(received) != 1 || received[0].Kind != notify.KindExtended {
+			t.Errorf("Expected only the extended notification, got %+v (pending %v)", received, pending)
+		}
+		// The dropped notification counts as delivered, before the extension
+		if !slices.Equal(order, []string{"expiring", "extended"}) {
+			t.Errorf("Expected onSent of the dropped notification first, got %v", order)
+		}
+	})
+}
+
+func TestGetEnvQuietHours(t *testing.T) {
+	t.Setenv("NOTIFY_QUIET_HOURS", "22:00-07:00 UTC")
+	quiet, ok := getEnvQuietHours(Env{Name: "env1", NotifyTimezone: "Asia/Tokyo"})
+	if !ok || quiet.Location.String() != "Asia/Tokyo" || quiet.StartMinute != 22*60 {
+		t.Errorf("Expected quiet hours in env timezone, got %+v", quiet)
+	}
+	t.Setenv("NOTIFY_QUIET_HOURS", "late")
+	if _, ok := getEnvQuietHours(Env{Name: "env1"}); ok {
+		t.Error("Expected invalid quiet hours to be ignored")
+	}
+}
@@ -0,0 +1,249 @@
+package kelm
+
+import (
+	"container/heap"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Countdown - action the scheduler runs for an env at FireAt
+type Countdown struct {
+	EnvName  string
+	Scenario string  // scenarioRemoval or scenarioNotification
+	Factor   float64 // notification factor, 0 for removal
+	FireAt   time.Time
+	// Namespaces passed to the callback
+	Namespaces []string
+	Callback   CountdownCallback
+	index      int
+	// Popped from the heap, the callback is running
+	firing bool
+}
+
+func (c *Countdown) sameAction(other Countdown) bool {
+	return c.Scenario == other.Scenario && c.Factor == other.Factor
+}
+
+// countdownHeap orders countdowns by FireAt, it implements heap.Interface
+type countdownHeap []*Countdown
+
+func (h countdownHeap) Len() int           { return len(h) }
+func (h countdownHeap) Less(i, j int) bool { return h[i].FireAt.Before(h[j].FireAt) }
+func (h countdownHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *countdownHeap) Push(x any) {
+	countdown := x.(*Countdown)
+	countdown.index = len(*h)
+	*h = append(*h, countdown)
+}
+
+func (h *countdownHeap) Pop() any {
+	old := *h
+	countdown := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	countdown.index = -1
+	return countdown
+}
+
+// Scheduler runs the countdowns of all envs from one goroutine and one timer.
+// Countdowns are kept in a heap by FireAt and indexed by env, so an env change updates them in place.
+// A fired countdown stays indexed until its callback returns, so a sync does not schedule it again.
+type Scheduler struct {
+	mu     sync.Mutex
+	queue  countdownHeap
+	byEnv  map[string][]*Countdown
+	wakeup chan struct{}
+}
+
+func NewScheduler() *Scheduler {
+	return &Scheduler{
+		byEnv:  make(map[string][]*Countdown),
+		wakeup: make(chan struct{}, 1),
+	}
+}
+
+// SyncEnv makes countdowns the only pending countdowns of the env.
+// Countdowns of the same scenario and factor are moved in place, missing ones are cancelled.
+// Firing countdowns are kept as they are.
+func (s *Scheduler) SyncEnv(envName string, countdowns []Countdown) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current := s.byEnv[envName]
+	synced := make([]*Countdown, 0, len(countdowns))
+	for _, countdown := range countdowns {
+		countdown.EnvName = envName
+		i := slices.IndexFunc(current, func(c *Countdown) bool { return c.sameAction(countdown) })
+		if i < 0 {
+			entry := countdown
+			heap.Push(&s.queue, &entry)
+			synced = append(synced, &entry)
+			logrus.Debugf("Env '%s' %s countdown scheduled at %s", envName, countdown.Scenario, countdown.FireAt.Format(time.RFC3339))
+			continue
+		}
+		entry := current[i]
+		current = slices.Delete(current, i, i+1)
+		if entry.firing {
+			synced = append(synced, entry)
+			continue
+		}
+		entry.Namespaces, entry.Callback = countdown.Namespaces, countdown.Callback
+		if !entry.FireAt.Equal(countdown.FireAt) {
+			logrus.Debugf("Env '%s' %s countdown moved from %s to %s", envName, countdown.Scenario, entry.FireAt.Format(time.RFC3339), countdown.FireAt.Format(time.RFC3339))
+			entry.FireAt = countdown.FireAt
+			heap.Fix(&s.queue, entry.index)
+		}
+		synced = append(synced, entry)
+	}
+	for _, stale := range current {
+		if stale.firing {
+			synced = append(synced, stale)
+			continue
+		}
+		heap.Remove(&s.queue, stale.index)
+		logrus.Debugf("Env '%s' %s countdown cancelled", envName, stale.Scenario)
+	}
+	if len(synced) == 0 {
+		delete(s.byEnv, envName)
+	} else {
+		s.byEnv[envName] = synced
+	}
+	s.wake()
+}
+
+// CancelEnv drops every pending countdown of the env
+func (s *Scheduler) CancelEnv(envName string) {
+	s.SyncEnv(envName, nil)
+}
+
+// CancelAll drops every pending countdown, firing ones stay until their callbacks return
+func (s *Scheduler) CancelAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queue = nil
+	for envName, countdowns := range s.byEnv {
+		firing := slices.DeleteFunc(countdowns, func(c *Countdown) bool { return !c.firing })
+		if len(firing) == 0 {
+			delete(s.byEnv, envName)
+		} else {
+			s.byEnv[envName] = firing
+		}
+	}
+	s.wake()
+}
+
+// Countdowns returns a copy of the pending countdowns, the next one first
+func (s *Scheduler) Countdowns() []Countdown {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	countdowns := make([]Countdown, 0, len(s.queue))
+	for _, countdown := range s.queue {
+		countdowns = append(countdowns, *countdown)
+	}
+	slices.SortStableFunc(countdowns, func(a, b Countdown) int { return a.FireAt.Compare(b.FireAt) })
+	return countdowns
+}
+
+// EnvNames returns the envs with pending or firing countdowns
+func (s *Scheduler) EnvNames() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names := make([]string, 0, len(s.byEnv))
+	for envName := range s.byEnv {
+		names = append(names, envName)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Run fires due countdowns until ctx is done. Callbacks run in their own goroutines,
+// so a long deletion does not delay other countdowns.
+func (s *Scheduler) Run(ctx context.Context) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		for _, countdown := range s.popDue(time.Now()) {
+			go s.fire(countdown)
+		}
+		next, ok := s.nextFireAt()
+		if ok {
+			timer.Reset(time.Until(next))
+		} else {
+			timer.Stop()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wakeup:
+		case <-timer.C:
+		}
+	}
+}
+
+// popDue takes the countdowns due at now from the heap and marks them firing
+func (s *Scheduler) popDue(now time.Time) []*Countdown {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var due []*Countdown
+	for len(s.queue) > 0 && !s.queue[0].FireAt.After(now) {
+		countdown := heap.Pop(&s.queue).(*Countdown)
+		countdown.firing = true
+		due = append(due, countdown)
+	}
+	return due
+}
+
+// fire runs the callback of a popped countdown and forgets it afterwards.
+// Firing countdowns are not changed by SyncEnv, so they are read without s.mu.
+func (s *Scheduler) fire(countdown *Countdown) {
+	defer s.finish(countdown)
+	fireCountdown(*countdown)
+}
+
+// finish forgets a fired countdown, a later sync may schedule it again
+func (s *Scheduler) finish(countdown *Countdown) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	remaining := slices.DeleteFunc(s.byEnv[countdown.EnvName], func(c *Countdown) bool { return c == countdown })
+	if len(remaining) == 0 {
+		delete(s.byEnv, countdown.EnvName)
+	} else {
+		s.byEnv[countdown.EnvName] = remaining
+	}
+}
+
+func (s *Scheduler) nextFireAt() (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.queue) == 0 {
+		return time.Time{}, false
+	}
+	return s.queue[0].FireAt, true
+}
+
+// wake makes Run look at the earliest countdown again, s.mu must be held
+func (s *Scheduler) wake() {
+	select {
+	case s.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+func fireCountdown(countdown Countdown) {
+	if countdown.Scenario == scenarioRemoval {
+		logrus.Infof("Force deleting namespaces for env '%s': %v", countdown.EnvName, countdown.Namespaces)
+	} else {
+		logrus.Debugf("Env '%s' TTL expired for scenario %s!", countdown.EnvName, countdown.Scenario)
+	}
+	if countdown.Callback != nil {
+		countdown.Callback(countdown.Namespaces)
+	}
+}
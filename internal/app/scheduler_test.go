@@ -0,0 +1,189 @@
+package kelm
+
+import (
+	"context"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSchedulerSyncEnv(t *testing.T) {
+	scheduler := NewScheduler()
+	now := time.Now()
+	scheduler.SyncEnv("env1", []Countdown{
+		{Scenario: scenarioRemoval, FireAt: now.Add(time.Hour)},
+		{Scenario: scenarioNotification, Factor: 0.5, FireAt: now.Add(30 * time.Minute)},
+		{Scenario: scenarioNotification, Factor: 0.9, FireAt: now.Add(54 * time.Minute)},
+	})
+	scheduler.SyncEnv("env2", []Countdown{{Scenario: scenarioRemoval, FireAt: now.Add(10 * time.Minute)}})
+
+	countdowns := scheduler.Countdowns()
+	var order []string
+	for _, countdown := range countdowns {
+		order = append(order, countdown.EnvName+"/"+countdown.Scenario)
+	}
+	expected := []string{"env2/removal", "env1/notification", "env1/notification", "env1/removal"}
+	if !slices.Equal(order, expected) {
+		t.Fatalf("Expected countdowns %v, got %v", expected, order)
+	}
+	if !slices.Equal(scheduler.EnvNames(), []string{"env1", "env2"}) {
+		t.Errorf("Unexpected envs %v", scheduler.EnvNames())
+	}
+
+	// env1 was extended and lost its 0.5 notification
+	scheduler.SyncEnv("env1", []Countdown{
+		{Scenario: scenarioRemoval, FireAt: now.Add(2 * time.Hour)},
+		{Scenario: scenarioNotification, Factor: 0.9, FireAt: now.Add(108 * time.Minute)},
+	})
+	countdowns = scheduler.Countdowns()
+	if len(countdowns) != 3 {
+		t.Fatalf("Expected stale countdown to be cancelled, got %+v", countdowns)
+	}
+	if countdowns[1].Factor != 0.9 || !countdowns[1].FireAt.Equal(now.Add(108*time.Minute)) {
+		t.Errorf("Expected notification to move, got %+v", countdowns[1])
+	}
+	if countdowns[2].Scenario != scenarioRemoval || !countdowns[2].FireAt.Equal(now.Add(2*time.Hour)) {
+		t.Errorf("Expected removal to move, got %+v", countdowns[2])
+	}
+
+	scheduler.CancelEnv("env1")
+	if !slices.Equal(scheduler.EnvNames(), []string{"env2"}) || len(scheduler.Countdowns()) != 1 {
+		t.Errorf("Expected only env2 countdown, got %+v", scheduler.Countdowns())
+	}
+	scheduler.CancelAll()
+	if len(scheduler.Countdowns()) != 0 || len(scheduler.EnvNames()) != 0 {
+		t.Errorf("Expected no countdowns, got %+v", scheduler.Countdowns())
+	}
+}
+
+func TestSchedulerPopDue(t *testing.T) {
+	scheduler := NewScheduler()
+	now := time.Now()
+	scheduler.SyncEnv("env1", []Countdown{
+		{Scenario: scenarioRemoval, FireAt: now.Add(time.Hour)},
+		{Scenario: scenarioNotification, Factor: 0.5, FireAt: now.Add(-time.Second)},
+	})
+	scheduler.SyncEnv("env2", []Countdown{{Scenario: scenarioRemoval, FireAt: now}})
+
+	due := scheduler.popDue(now)
+	if len(due) != 2 || due[0].EnvName != "env1" || due[1].EnvName != "env2" {
+		t.Fatalf("Expected due countdowns of env1 and env2, got %+v", due)
+	}
+	if !slices.Equal(scheduler.EnvNames(), []string{"env1", "env2"}) {
+		t.Errorf("Expected firing env2 to be kept, got %v", scheduler.EnvNames())
+	}
+	if next, ok := scheduler.nextFireAt(); !ok || !next.Equal(now.Add(time.Hour)) {
+		t.Errorf("Expected env1 removal next, got %v", next)
+	}
+	scheduler.finish(due[1])
+	if !slices.Equal(scheduler.EnvNames(), []string{"env1"}) {
+		t.Errorf("Expected fired env2 to be forgotten, got %v", scheduler.EnvNames())
+	}
+}
+
+func TestSchedulerSyncFiringEnv(t *testing.T) {
+	scheduler := NewScheduler()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go scheduler.Run(ctx)
+
+	var fired atomic.Int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	removal := Countdown{Scenario: scenarioRemoval, FireAt: time.Now(), Callback: func([]string) {
+		if fired.Add(1) == 1 {
+			close(started)
+		}
+		<-release
+	}}
+	scheduler.SyncEnv("env1", []Countdown{removal})
+	select {
+	case <-started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected removal to fire")
+	}
+
+	// A resync during the deletion sees the env still expired
+	scheduler.SyncEnv("env1", []Countdown{removal})
+	scheduler.CancelAll()
+	scheduler.SyncEnv("env1", []Countdown{removal})
+	time.Sleep(100 * time.Millisecond)
+	if fired.Load() != 1 || len(scheduler.Countdowns()) != 0 {
+		t.Errorf("Expected the firing removal not to be scheduled again, fired %d, pending %+v", fired.Load(), scheduler.Countdowns())
+	}
+
+	close(release)
+	deadline := time.Now().Add(2 * time.Second)
+	for len(scheduler.EnvNames()) != 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if names := scheduler.EnvNames(); len(names) != 0 {
+		t.Errorf("Expected finished removal to be forgotten, got %v", names)
+	}
+}
+
+func TestSchedulerRun(t *testing.T) {
+	scheduler := NewScheduler()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go scheduler.Run(ctx)
+
+	fired := make(chan []string, 2)
+	callback := func(namespaces []string) { fired <- namespaces }
+	scheduler.SyncEnv("env1", []Countdown{{Scenario: scenarioRemoval, FireAt: time.Now().Add(time.Hour), Namespaces: []string{"ns1"}, Callback: callback}})
+	// Moving the countdown earlier wakes the scheduler up
+	scheduler.SyncEnv("env1", []Countdown{{Scenario: scenarioRemoval, FireAt: time.Now().Add(50 * time.Millisecond), Namespaces: []string{"ns1", "ns2"}, Callback: callback}})
+	select {
+	case namespaces := <-fired:
+		if !slices.Equal(namespaces, []string{"ns1", "ns2"}) {
+			t.Errorf("Expected callback with updated namespaces, got %v", namespaces)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected countdown to fire")
+	}
+
+	scheduler.SyncEnv("env2", []Countdown{{Scenario: scenarioRemoval, FireAt: time.Now().Add(50 * time.Millisecond), Callback: callback}})
+	scheduler.CancelEnv("env2")
+	select {
+	case namespaces := <-fired:
+		t.Errorf("Expected cancelled countdown not to fire, got %v", namespaces)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestGetRemovalCountdown(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour).UTC()
+	tests := []struct {
+		name     string
+		env      Env
+		expected bool
+		due      bool
+	}{
+		{name: "deadline", env: Env{Name: "env1", ExpiresAt: expiresAt, RemainingTtl: time.Hour}, expected: true},
+		{name: "expired", env: Env{Name: "env1", ExpiresAt: time.Now().Add(-time.Hour)}, expected: true, due: true},
+		{name: "interrupted deletion", env: Env{Name: "env1", ExpiresAt: expiresAt, DeletionInterrupted: time.Now()}, expected: true, due: true},
+		{name: "held", env: Env{Name: "env1", Hold: true}},
+		{name: "held until", env: Env{Name: "env1", Hold: true, ExpiresAt: expiresAt, RemainingTtl: time.Hour}, expected: true},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			countdown, ok := getRemovalCountdown(nil, testCase.env)
+			if ok != testCase.expected {
+				t.Fatalf("Expected countdown %v, got %v", testCase.expected, ok)
+			}
+			if !ok {
+				return
+			}
+			if countdown.Scenario != scenarioRemoval || countdown.Callback == nil {
+				t.Errorf("Unexpected countdown %+v", countdown)
+			}
+			if due := !countdown.FireAt.After(time.Now()); due != testCase.due {
+				t.Errorf("Expected due %v, got fire at %v", testCase.due, countdown.FireAt)
+			}
+			if !testCase.due && !countdown.FireAt.Equal(expiresAt) {
+				t.Errorf("Expected fire at deadline %v, got %v", expiresAt, countdown.FireAt)
+			}
+		})
+	}
+}
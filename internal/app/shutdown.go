@@ -0,0 +1,159 @@
+package kelm
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"kelm/internal/pkg/timer"
+
+	"github.com/sirupsen/logrus"
+	core "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Envs with a running delete callback
+var runningDeletions = make(map[string]Env)
+var runningDeletionsMu sync.Mutex
+
+// getShutdownGracePeriod covers a Zarf package removal (10m) and the namespace force-delete (1m)
+func getShutdownGracePeriod() time.Duration {
+	return getDurationEnv("SHUTDOWN_GRACE_PERIOD", 11*time.Minute)
+}
+
+func startDeletion(env Env) {
+	runningDeletionsMu.Lock()
+	defer runningDeletionsMu.Unlock()
+	runningDeletions[env.Name] = env
+}
+
+func finishDeletion(envName string) {
+	runningDeletionsMu.Lock()
+	defer runningDeletionsMu.Unlock()
+	delete(runningDeletions, envName)
+}
+
+// drainDeletions waits up to gracePeriod for running delete callbacks and returns the envs still being deleted
+func drainDeletions(gracePeriod time.Duration) []Env {
+	deadline := time.Now().Add(gracePeriod)
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		runningDeletionsMu.Lock()
+		running := len(runningDeletions)
+		if running == 0 || !time.Now().Before(deadline) {
+			envs := make([]Env, 0, running)
+			for _, env := range runningDeletions {
+				envs = append(envs, env)
+			}
+			runningDeletionsMu.Unlock()
+			slices.SortFunc(envs, func(a, b Env) int { return strings.Compare(a.Name, b.Name) })
+			return envs
+		}
+		runningDeletionsMu.Unlock()
+		<-ticker.C
+	}
+}
+
+// parseDeletionInterrupted returns when shutdown interrupted the namespace deletion, zero time if it did not
+func parseDeletionInterrupted(ns core.Namespace) time.Time {
+	// Status is written by kelm, a broken one only means the deletion waits for the deadline again
+	return parseStatusTime(ns, "kelm.riftonix.io/status.deletionInterrupted")
+}
+
+// parseDeletionStarted returns when a leader started the namespace deletion, zero time if none is running
+func parseDeletionStarted(ns core.Namespace) time.Time {
+	// A broken one only means the deletion does not wait for the previous leader
+	return parseStatusTime(ns, "kelm.riftonix.io/status.deletionStarted")
+}
+
+func parseStatusTime(ns core.Namespace, annotation string) time.Time {
+	s := ns.Annotations[annotation]
+	if s == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		logrus.Warnf("Ignoring namespace %s annotation %s '%s': %v", ns.Name, annotation, s, err)
+		return time.Time{}
+	}
+	return t.UTC()
+}
+
+// getDeletionHandover returns how long a deletion started by another leader may still run.
+// That leader waits SHUTDOWN_GRACE_PERIOD after losing the Lease, which it may hold for a lease duration unrenewed.
+func getDeletionHandover() time.Duration {
+	handover := getShutdownGracePeriod()
+	if isLeaderElectionEnabled() {
+		handover += getLeaseDuration()
+	}
+	return handover
+}
+
+// recordRunningDeletion marks the namespaces whose deletion starts,
+// so the next leader waits for this one to finish or to record the interruption
+func recordRunningDeletion(client kubernetes.Interface, env Env, namespaces []string) {
+	patch := fmt.Sprintf(`{"metadata":{"annotations":{"kelm.riftonix.io/status.deletionStarted":%q}}}`, time.Now().UTC().Format(time.RFC3339))
+	for _, ns := range namespaces {
+		_, err := client.CoreV1().Namespaces().Patch(context.Background(), ns, types.MergePatchType, []byte(patch), meta.PatchOptions{})
+		if err != nil && !kerrors.IsNotFound(err) {
+			logrus.Errorf("Failed to record deletion start of env '%s' on namespace %s: %v", env.Name, ns, err)
+		}
+	}
+}
+
+// recordFinishedDeletion drops the deletion start from namespaces that survived the deletion, so their retry does not wait
+func recordFinishedDeletion(client kubernetes.Interface, env Env, namespaces []string) {
+	patch := `{"metadata":{"annotations":{"kelm.riftonix.io/status.deletionStarted":null}}}`
+	for _, ns := range namespaces {
+		_, err := client.CoreV1().Namespaces().Patch(context.Background(), ns, types.MergePatchType, []byte(patch), meta.PatchOptions{})
+		if err != nil && !kerrors.IsNotFound(err) {
+			logrus.Errorf("Failed to record deletion end of env '%s' on namespace %s: %v", env.Name, ns, err)
+		}
+	}
+}
+
+// recordInterruptedDeletions marks the namespaces of envs whose deletion was cut off,
+// the next operator start resumes their deletion at once
+func recordInterruptedDeletions(client kubernetes.Interface, envs []Env) {
+	patch := fmt.Sprintf(`{"metadata":{"annotations":{"kelm.riftonix.io/status.deletionInterrupted":%q,"kelm.riftonix.io/status.deletionStarted":null}}}`, time.Now().UTC().Format(time.RFC3339))
+	for _, env := range envs {
+		for _, ns := range env.Namespaces {
+			_, err := client.CoreV1().Namespaces().Patch(context.Background(), ns, types.MergePatchType, []byte(patch), meta.PatchOptions{})
+			if kerrors.IsNotFound(err) {
+				continue
+			}
+			if err != nil {
+				logrus.Errorf("Failed to record interrupted deletion on namespace %s: %v", ns, err)
+			}
+		}
+		logrus.Warnf("Deletion of env '%s' was interrupted, it resumes on the next start", env.Name)
+	}
+}
+
+// finishRunningDeletions waits for running deletions and records the ones that did not finish in SHUTDOWN_GRACE_PERIOD.
+// Countdowns must be stopped before, so no new deletion starts.
+func finishRunningDeletions(client kubernetes.Interface) {
+	gracePeriod := getShutdownGracePeriod()
+	logrus.Infof("Waiting up to %s for running deletions", timer.FormatDuration(gracePeriod))
+	if interrupted := drainDeletions(gracePeriod); len(interrupted) > 0 {
+		recordInterruptedDeletions(client, interrupted)
+	}
+}
+
+func shutdown(client kubernetes.Interface) {
+	finishRunningDeletions(client)
+	logrus.Info("Operator stopped")
+}
+
+// stepDown finishes the deletions of a lost leader term, so the next leader resumes the unfinished ones
+func stepDown(client kubernetes.Interface) {
+	finishRunningDeletions(client)
+	logrus.Info("Leader term stopped")
+}
@@ -0,0 +1,142 @@
+package kelm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestDrainDeletions(t *testing.T) {
+	if envs := drainDeletions(time.Second); len(envs) != 0 {
+		t.Fatalf("Expected no running deletions, got %v", envs)
+	}
+
+	startDeletion(Env{Name: "env1"})
+	startDeletion(Env{Name: "env2"})
+	t.Cleanup(func() {
+		finishDeletion("env1")
+		finishDeletion("env2")
+	})
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		finishDeletion("env1")
+	}()
+	envs := drainDeletions(300 * time.Millisecond)
+	if len(envs) != 1 || envs[0].Name != "env2" {
+		t.Errorf("Expected env2 to be interrupted, got %v", envs)
+	}
+}
+
+func TestRecordInterruptedDeletions(t *testing.T) {
+	client := fake.NewSimpleClientset(&core.Namespace{ObjectMeta: meta.ObjectMeta{Name: "ns1"}})
+	recordRunningDeletion(client, Env{Name: "env1"}, []string{"ns1", "gone"})
+	recordInterruptedDeletions(client, []Env{{Name: "env1", Namespaces: []string{"ns1", "gone"}}})
+
+	ns, err := client.CoreV1().Namespaces().Get(context.Background(), "ns1", meta.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get namespace: %v", err)
+	}
+	if parseDeletionInterrupted(*ns).IsZero() || !parseDeletionStarted(*ns).IsZero() {
+		t.Errorf("Expected interrupted deletion to replace the running one, got %v", ns.Annotations)
+	}
+}
+
+func TestRecordFinishedDeletion(t *testing.T) {
+	client := fake.NewSimpleClientset(&core.Namespace{ObjectMeta: meta.ObjectMeta{Name: "ns1"}})
+	recordRunningDeletion(client, Env{Name: "env1"}, []string{"ns1"})
+
+	ns, err := client.CoreV1().Namespaces().Get(context.Background(), "ns1", meta.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get namespace: %v", err)
+	}
+	if parseDeletionStarted(*ns).IsZero() {
+		t.Fatalf("Expected running deletion to be recorded, got %v", ns.Annotations)
+	}
+
+	recordFinishedDeletion(client, Env{Name: "env1"}, []string{"ns1", "gone"})
+	ns, err = client.CoreV1().Namespaces().Get(context.Background(), "ns1", meta.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get namespace: %v", err)
+	}
+	if _, ok := ns.Annotations["kelm.riftonix.io/status.deletionStarted"]; ok {
+		t.Errorf("Expected running deletion marker to be removed, got %v", ns.Annotations)
+	}
+}
+
+func TestGetRemovalCountdownWaitsForPreviousLeader(t *testing.T) {
+	t.Setenv("SHUTDOWN_GRACE_PERIOD", "10m")
+	t.Setenv("LEADER_ELECTION_ENABLED", "true")
+	t.Setenv("LEADER_ELECTION_LEASE_DURATION", "15s")
+	started := time.Now().Add(-time.Minute).Truncate(time.Second)
+	validTime := time.Now().UTC().Format(time.RFC3339)
+	running := makeNamespace("ns1", "env1", "1h", "0.5", "[]", validTime, time.Now().Add(-2*time.Hour), "true")
+	running.Annotations["kelm.riftonix.io/status.deletionStarted"] = started.UTC().Format(time.RFC3339)
+	expired := makeNamespace("ns2", "env2", "1h", "0.5", "[]", validTime, time.Now().Add(-2*time.Hour), "true")
+
+	envs, err := getEnvs(newNamespaceLister(running, expired), labels.Set{"kelm.riftonix.io/managed": "true"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	countdown, ok := getRemovalCountdown(nil, envs["env1"])
+	if want := started.Add(10*time.Minute + 15*time.Second); !ok || !countdown.FireAt.Equal(want) {
+		t.Errorf("Expected deletion to wait for the previous leader until %v, got %v", want, countdown.FireAt)
+	}
+	countdown, ok = getRemovalCountdown(nil, envs["env2"])
+	if !ok || time.Until(countdown.FireAt) > time.Second {
+		t.Errorf("Expected expired env to be deleted at once, got %v", countdown.FireAt)
+	}
+}
+
+func TestStepDown(t *testing.T) {
+	t.Setenv("SHUTDOWN_GRACE_PERIOD", "200ms")
+	client := fake.NewSimpleClientset(
+		&core.Namespace{ObjectMeta: meta.ObjectMeta{Name: "ns1"}},
+		&core.Namespace{ObjectMeta: meta.ObjectMeta{Name: "ns2"}},
+	)
+	startDeletion(Env{Name: "env1", Namespaces: []string{"ns1"}})
+	startDeletion(Env{Name: "env2", Namespaces: []string{"ns2"}})
+	t.Cleanup(func() {
+		finishDeletion("env1")
+		finishDeletion("env2")
+	})
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		finishDeletion("env1")
+	}()
+	stepDown(client)
+
+	for name, interrupted := range map[string]bool{"ns1": false, "ns2": true} {
+		ns, err := client.CoreV1().Namespaces().Get(context.Background(), name, meta.GetOptions{})
+		if err != nil {
+			t.Fatalf("Failed to get namespace: %v", err)
+		}
+		if recorded := !parseDeletionInterrupted(*ns).IsZero(); recorded != interrupted {
+			t.Errorf("Namespace %s: expected interrupted %v, got %v", name, interrupted, ns.Annotations)
+		}
+	}
+}
+
+func TestGetEnvsDeletionInterrupted(t *testing.T) {
+	validTime := time.Now().UTC().Format(time.RFC3339)
+	interrupted := makeNamespace("ns1", "env1", "24h", "0.5", "[]", validTime, time.Now(), "true")
+	interrupted.Annotations["kelm.riftonix.io/hold"] = "true"
+	interrupted.Annotations["kelm.riftonix.io/status.deletionInterrupted"] = "2026-10-16T10:00:00Z"
+	broken := makeNamespace("ns2", "env2", "24h", "0.5", "[]", validTime, time.Now(), "true")
+	broken.Annotations["kelm.riftonix.io/status.deletionInterrupted"] = "yesterday"
+
+	envs, err := getEnvs(newNamespaceLister(interrupted, broken), labels.Set{"kelm.riftonix.io/managed": "true"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if env := envs["env1"]; env.RemainingTtl != 0 || env.Hold || env.DeletionInterrupted.IsZero() {
+		t.Errorf("Expected interrupted env to be deleted at once, got ttl %v, hold %v", env.RemainingTtl, env.Hold)
+	}
+	if env := envs["env2"]; env.RemainingTtl <= 0 || !env.DeletionInterrupted.IsZero() {
+		t.Errorf("Expected broken marker to be ignored, got ttl %v", env.RemainingTtl)
+	}
+}
@@ -0,0 +1,108 @@
+package kelm
+
+import (
+	"context"
+	"os"
+	"strings"
+	"time"
+
+	"kelm/internal/pkg/notify"
+
+	"github.com/sirupsen/logrus"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Notification templates shared by all notifiers, empty until a ConfigMap is loaded
+var notifyTemplates = &notify.Templates{}
+
+// getTemplatesConfigMap returns namespace and name from NOTIFY_TEMPLATES_CONFIGMAP="namespace/name"
+func getTemplatesConfigMap() (string, string, bool) {
+	s := os.Getenv("NOTIFY_TEMPLATES_CONFIGMAP")
+	if s == "" {
+		return "", "", false
+	}
+	namespace, name, ok := strings.Cut(s, "/")
+	if !ok || namespace == "" || name == "" {
+		logrus.Warnf("Invalid NOTIFY_TEMPLATES_CONFIGMAP %q, expected namespace/name, using built-in messages", s)
+		return "", "", false
+	}
+	return namespace, name, true
+}
+
+// templateSamples are rendered at load, so templates with unknown fields are rejected before use
+func templateSamples() []notify.Notification {
+	now := time.Now()
+	env := Env{
+		Name:                   "example",
+		Namespaces:             []string{"example-api", "example-db"},
+		Aggregation:            aggregationMax,
+		RemainingTtl:           time.Hour,
+		ReplenishRatio:         defaultReplenishRatio,
+		NotificationFactors:    []float64{0.5},
+		NotificationTimestamps: []time.Time{now.Add(-time.Hour)},
+		CreationTimestamp:      now.Add(-2 * time.Hour),
+		UpdateTimestamp:        now.Add(-2 * time.Hour),
+		AnchorTimestamp:        now.Add(-2 * time.Hour),
+		ExpiresAt:              now.Add(time.Hour),
+		Clock:                  "wall",
+		NotifyChannels:         []string{"#example"},
+		OwnerEmails:            []string{"owner@example.com"},
+	}
+	expiring := newNotification(env, notify.KindExpiring)
+	expiring.Factor = 0.5
+	report := &notify.TeardownReport{
+		Namespaces: []notify.NamespaceReport{
+			{Namespace: "example-api", State: "deleted", Duration: "12s"},
+			{Namespace: "example-db", State: "timeout", Duration: "1m0s", Error: "finalizers are not removed"},
+		},
+	}
+	deleted := newNotification(env, notify.KindDeleted)
+	deleted.Report = report
+	failed := newNotification(env, notify.KindDeletionFailed)
+	failed.Report = &notify.TeardownReport{Namespaces: report.Namespaces, RetryAt: now.Add(time.Minute)}
+	return []notify.Notification{expiring, newNotification(env, notify.KindExtended), deleted, failed}
+}
+
+func loadTemplates(configMap *core.ConfigMap) {
+	if err := notifyTemplates.Load(configMap.Data, templateSamples()); err != nil {
+		logrus.Errorf("Invalid notification templates in ConfigMap %s/%s, keeping the previous ones: %v", configMap.Namespace, configMap.Name, err)
+		recordConfigMapEvent(configMap, core.EventTypeWarning, reasonInvalidTemplates, err.Error())
+		return
+	}
+	logrus.Infof("Loaded %d notification templates from ConfigMap %s/%s", len(configMap.Data), configMap.Namespace, configMap.Name)
+}
+
+// watchTemplates keeps the notification templates in sync with the ConfigMap until ctx is done
+func watchTemplates(ctx context.Context, client kubernetes.Interface, namespace string, name string) {
+	factory := informers.NewSharedInformerFactoryWithOptions(client, 0, informers.WithNamespace(namespace), informers.WithTweakListOptions(func(options *meta.ListOptions) {
+		options.FieldSelector = fields.OneTermEqualSelector("metadata.name", name).String()
+	}))
+	_, err := factory.Core().V1().ConfigMaps().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj any) {
+			if configMap, ok := obj.(*core.ConfigMap); ok {
+				loadTemplates(configMap)
+			}
+		},
+		UpdateFunc: func(_, obj any) {
+			if configMap, ok := obj.(*core.ConfigMap); ok {
+				loadTemplates(configMap)
+			}
+		},
+		DeleteFunc: func(any) {
+			_ = notifyTemplates.Load(nil, nil)
+			logrus.Warnf("Notification templates ConfigMap %s/%s was deleted, using built-in messages", namespace, name)
+		},
+	})
+	if err != nil {
+		logrus.Errorf("Failed to watch notification templates ConfigMap %s/%s: %v", namespace, name, err)
+		return
+	}
+	factory.Start(ctx.Done())
+	<-ctx.Done()
+	factory.Shutdown()
+}
@@ -0,0 +1,162 @@
+package kelm
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"kelm/internal/pkg/notify"
+
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// useTemplatesWebhook returns a webhook rendering notifyTemplates and the bodies it posts
+func useTemplatesWebhook(t *testing.T) (*notify.Webhook, chan string) {
+	t.Cleanup(func() { _ = notifyTemplates.Load(nil, nil) })
+	bodies := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies <- string(body)
+	}))
+	t.Cleanup(server.Close)
+	webhook := notify.NewWebhook(server.URL, time.Second, 0, 0)
+	webhook.Templates = notifyTemplates
+	return webhook, bodies
+}
+
+func TestLoadTemplates(t *testing.T) {
+	tests := []struct {
+		name         string
+		template     string
+		expectedBody string
+		expectEvent  bool
+	}{
+		{"valid", "{{ .Env.Name }} {{ .Kind }}", "env1 expiring", false},
+		{"report of teardown kinds", "{{ .Kind }}{{ with .Report }} retry {{ .RetryAt.IsZero }}{{ end }}", "expiring", false},
+		{"parse error", "{{ .Env.Name ", "previous", true},
+		{"unknown field", "{{ .Env.Owner }}", "previous", true},
+		// Samples of every kind are rendered, the expiring one has no report
+		{"field of one kind only", "{{ .Report.RetryAt }}", "previous", true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			fakeRecorder := useRecorder(t)
+			webhook, bodies := useTemplatesWebhook(t)
+			if err := notifyTemplates.Load(map[string]string{notify.TemplateWebhook: "previous"}, nil); err != nil {
+				t.Fatalf("Failed to load previous templates: %v", err)
+			}
+			configMap := &core.ConfigMap{
+				ObjectMeta: meta.ObjectMeta{Name: "kelm-templates", Namespace: "kelm", UID: "uid-1"},
+				Data:       map[string]string{notify.TemplateWebhook: testCase.template},
+			}
+
+			loadTemplates(configMap)
+			events := drainEvents(fakeRecorder)
+			if testCase.expectEvent != (len(events) == 1) || len(events) > 1 {
+				t.Fatalf("Expected event %v, got %v", testCase.expectEvent, events)
+			}
+			if testCase.expectEvent && !strings.HasPrefix(events[0], core.EventTypeWarning+" "+reasonInvalidTemplates) {
+				t.Errorf("Expected InvalidTemplates warning, got %q", events[0])
+			}
+			env := Env{Name: "env1", Namespaces: []string{"ns1"}, ExpiresAt: time.Now().Add(time.Hour)}
+			if err := webhook.Notify(context.Background(), newNotification(env, notify.KindExpiring)); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if body := <-bodies; body != testCase.expectedBody {
+				t.Errorf("Expected body %q, got %q", testCase.expectedBody, body)
+			}
+		})
+	}
+}
+
+func TestTemplateSamples(t *testing.T) {
+	samples := templateSamples()
+	kinds := make([]string, 0, len(samples))
+	for _, sample := range samples {
+		kinds = append(kinds, sample.Kind)
+	}
+	expected := []string{notify.KindExpiring, notify.KindExtended, notify.KindDeleted, notify.KindDeletionFailed}
+	if !slices.Equal(kinds, expected) {
+		t.Errorf("Expected samples %v, got %v", expected, kinds)
+	}
+	// Fields every notification has render for every sample
+	sources := map[string]string{notify.TemplateTitle: "{{ .Env.Name }}", notify.TemplateBody: "{{ join .Namespaces \", \" }} {{ .ExtendCommand }}"}
+	if err := new(notify.Templates).Load(sources, samples); err != nil {
+		t.Errorf("Expected samples to render, got %v", err)
+	}
+}
+
+func TestWatchTemplates(t *testing.T) {
+	fakeRecorder := useRecorder(t)
+	webhook, bodies := useTemplatesWebhook(t)
+
+	configMap := &core.ConfigMap{
+		ObjectMeta: meta.ObjectMeta{Name: "kelm-templates", Namespace: "kelm"},
+		Data:       map[string]string{notify.TemplateWebhook: `{{ .Env.Name }} ends at {{ .Env.ExpiresAt.Format "15:04" }}`},
+	}
+	client := fake.NewSimpleClientset(configMap)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go watchTemplates(ctx, client, "kelm", "kelm-templates")
+
+	env := Env{Name: "env1", Namespaces: []string{"ns1"}, ExpiresAt: time.Date(2026, 10, 16, 18, 0, 0, 0, time.UTC)}
+	expectBody := func(expected string) {
+		t.Helper()
+		var body string
+		for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+			if err := webhook.Notify(ctx, newNotification(env, notify.KindExpiring)); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if body = <-bodies; body == expected {
+				return
+			}
+		}
+		t.Errorf("Expected body %q, got %q", expected, body)
+	}
+	expectBody("env1 ends at 18:00")
+
+	// Invalid update is reported and the loaded templates stay
+	configMap.Data[notify.TemplateWebhook] = "{{ .Env.Owner }}"
+	if _, err := client.CoreV1().ConfigMaps("kelm").Update(ctx, configMap, meta.UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to update ConfigMap: %v", err)
+	}
+	select {
+	case event := <-fakeRecorder.Events:
+		if !strings.Contains(event, reasonInvalidTemplates) {
+			t.Errorf("Unexpected event %q", event)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected InvalidTemplates event")
+	}
+	expectBody("env1 ends at 18:00")
+
+	configMap.Data[notify.TemplateWebhook] = "{{ .Env.Name }} is leaving"
+	if _, err := client.CoreV1().ConfigMaps("kelm").Update(ctx, configMap, meta.UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to update ConfigMap: %v", err)
+	}
+	expectBody("env1 is leaving")
+
+	if err := client.CoreV1().ConfigMaps("kelm").Delete(ctx, configMap.Name, meta.DeleteOptions{}); err != nil {
+		t.Fatalf("Failed to delete ConfigMap: %v", err)
+	}
+	expectBody(`{"kind":"expiring","env":"env1","namespaces":["ns1"],"expiresAt":"2026-10-16T18:00:00Z","factor":0}`)
+}
+
+func TestGetTemplatesConfigMap(t *testing.T) {
+	t.Setenv("NOTIFY_TEMPLATES_CONFIGMAP", "kelm/kelm-templates")
+	if namespace, name, ok := getTemplatesConfigMap(); !ok || namespace != "kelm" || name != "kelm-templates" {
+		t.Errorf("Unexpected ConfigMap %s/%s (%v)", namespace, name, ok)
+	}
+	t.Setenv("NOTIFY_TEMPLATES_CONFIGMAP", "kelm-templates")
+	if _, _, ok := getTemplatesConfigMap(); ok {
+		t.Error("Expected ConfigMap without namespace to be rejected")
+	}
+}
@@ -0,0 +1,99 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Name of the alerts pushed to Alertmanager
+const AlertName = "KelmEnvExpiring"
+
+// How long a stuck env alert outlives its retry time, enough for the retry to report back
+const deletionAttemptTimeout = 5 * time.Minute
+
+// Alertmanager pushes an alert for every expiring env to the Alertmanager v2 API.
+// The alert fires until the env deadline, or through the retry of a stuck deletion,
+// and is resolved when the env is extended or deleted.
+type Alertmanager struct {
+	URL        string
+	Client     *http.Client
+	Retries    int
+	RetryDelay time.Duration
+	// Optional title and body templates for the summary and description
+	Templates *Templates
+}
+
+// alert - postableAlert of the Alertmanager v2 API
+type alert struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	StartsAt    time.Time         `json:"startsAt,omitzero"`
+	EndsAt      time.Time         `json:"endsAt"`
+}
+
+// NewAlertmanager takes the Alertmanager base URL, like "http://alertmanager:9093"
+func NewAlertmanager(url string, timeout time.Duration, retries int, retryDelay time.Duration) *Alertmanager {
+	return &Alertmanager{
+		URL:        strings.TrimSuffix(url, "/") + "/api/v2/alerts",
+		Client:     &http.Client{Timeout: timeout},
+		Retries:    retries,
+		RetryDelay: retryDelay,
+	}
+}
+
+func (a *Alertmanager) Notify(ctx context.Context, notification Notification) error {
+	if notification.skips(SinkAlertmanager) {
+		return nil
+	}
+	body, err := json.Marshal([]alert{a.format(notification, time.Now())})
+	if err != nil {
+		return err
+	}
+	return post(ctx, a.Client, a.URL, map[string]string{"Content-Type": "application/json"}, body, a.Retries, a.RetryDelay)
+}
+
+// format builds the alert for the notification as of now.
+// Labels identify the env, so a resolving alert replaces the firing one.
+func (a *Alertmanager) format(notification Notification, now time.Time) alert {
+	result := alert{
+		Labels: map[string]string{
+			"alertname":  AlertName,
+			"env":        notification.Env,
+			"namespaces": strings.Join(notification.Namespaces, ","),
+		},
+		Annotations: map[string]string{
+			"summary":   a.Templates.title(notification, now),
+			"expiresAt": notification.ExpiresAt.UTC().Format(time.RFC3339),
+		},
+	}
+	if len(notification.Owners) > 0 {
+		result.Labels["owner"] = strings.Join(notification.Owners, ",")
+	}
+	if notification.Kind == KindDeletionFailed && notification.Report != nil && !notification.Report.RetryAt.IsZero() {
+		// Keep firing through the retry, the retry outcome replaces the alert
+		result.StartsAt = now
+		result.EndsAt = notification.Report.RetryAt.Add(deletionAttemptTimeout)
+		result.Annotations["description"] = strings.Join(notification.Report.lines(), "\n")
+		if body, ok := a.Templates.render(TemplateBody, notification, now); ok {
+			result.Annotations["description"] = body
+		}
+		return result
+	}
+	if notification.Kind != KindExpiring {
+		// An alert that ends now is resolved
+		result.EndsAt = now
+		return result
+	}
+	result.StartsAt = now
+	result.EndsAt = notification.ExpiresAt
+	result.Annotations["factor"] = strconv.FormatFloat(notification.Factor, 'f', -1, 64)
+	result.Annotations["description"] = "Extend the environment with: " + ExtendCommand(notification.Namespaces)
+	if body, ok := a.Templates.render(TemplateBody, notification, now); ok {
+		result.Annotations["description"] = body
+	}
+	return result
+}
@@ -0,0 +1,76 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAlertmanagerFormat(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	expiresAt := now.Add(2 * time.Hour)
+	notification := Notification{
+		Kind:       KindExpiring,
+		Env:        "env1",
+		Namespaces: []string{"ns1", "ns2"},
+		ExpiresAt:  expiresAt,
+		Factor:     0.9,
+		Owners:     []string{"alice@example.com"},
+	}
+	alertmanager := NewAlertmanager("http://alertmanager:9093/", time.Second, 0, 0)
+	if alertmanager.URL != "http://alertmanager:9093/api/v2/alerts" {
+		t.Errorf("Unexpected URL %q", alertmanager.URL)
+	}
+
+	firing := alertmanager.format(notification, now)
+	if firing.Labels["alertname"] != AlertName || firing.Labels["env"] != "env1" ||
+		firing.Labels["namespaces"] != "ns1,ns2" || firing.Labels["owner"] != "alice@example.com" {
+		t.Errorf("Unexpected labels: %v", firing.Labels)
+	}
+	if !firing.StartsAt.Equal(now) || !firing.EndsAt.Equal(expiresAt) {
+		t.Errorf("Expected alert to fire until the deadline, got %v - %v", firing.StartsAt, firing.EndsAt)
+	}
+	if firing.Annotations["summary"] != "Environment env1 expires in 2h" || firing.Annotations["factor"] != "0.9" {
+		t.Errorf("Unexpected annotations: %v", firing.Annotations)
+	}
+
+	for _, kind := range []string{KindExtended, KindDeleted} {
+		notification.Kind = kind
+		resolved := alertmanager.format(notification, now)
+		if !resolved.EndsAt.Equal(now) || !resolved.StartsAt.IsZero() {
+			t.Errorf("Expected %s alert to be resolved now, got %v - %v", kind, resolved.StartsAt, resolved.EndsAt)
+		}
+		for _, label := range []string{"alertname", "env", "namespaces", "owner"} {
+			if resolved.Labels[label] != firing.Labels[label] {
+				t.Errorf("Expected %s alert to keep label %s, got %q", kind, label, resolved.Labels[label])
+			}
+		}
+	}
+}
+
+func TestAlertmanagerNotify(t *testing.T) {
+	var received []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/alerts" {
+			t.Errorf("Unexpected path %q", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("Failed to decode payload: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	notification := Notification{Kind: KindDeleted, Env: "env1", Namespaces: []string{"ns1"}}
+	if err := NewAlertmanager(server.URL, time.Second, 0, 0).Notify(context.Background(), notification); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(received) != 1 || received[0]["endsAt"] == nil {
+		t.Fatalf("Expected one resolved alert, got %v", received)
+	}
+	if _, ok := received[0]["startsAt"]; ok {
+		t.Errorf("Expected no startsAt on resolved alert, got %v", received[0]["startsAt"])
+	}
+}
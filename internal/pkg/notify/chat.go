@@ -0,0 +1,119 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Chat message flavors
+const (
+	FlavorSlack      = "slack"      // Block Kit
+	FlavorMattermost = "mattermost" // message attachments
+)
+
+// Chat posts notifications to a Slack or Mattermost incoming webhook
+type Chat struct {
+	URL        string
+	Flavor     string
+	Client     *http.Client
+	Retries    int
+	RetryDelay time.Duration
+	// Optional title and body templates
+	Templates *Templates
+}
+
+func NewChat(url string, flavor string, timeout time.Duration, retries int, retryDelay time.Duration) *Chat {
+	return &Chat{
+		URL:        url,
+		Flavor:     flavor,
+		Client:     &http.Client{Timeout: timeout},
+		Retries:    retries,
+		RetryDelay: retryDelay,
+	}
+}
+
+type chatMessage struct {
+	Channel     string           `json:"channel,omitempty"`
+	Text        string           `json:"text"`
+	Blocks      []map[string]any `json:"blocks,omitempty"`
+	Attachments []map[string]any `json:"attachments,omitempty"`
+}
+
+// Notify sends one message per env channel, or one message to the webhook default channel
+func (c *Chat) Notify(ctx context.Context, notification Notification) error {
+	// Extensions are done by the env users themselves, there is nothing to tell them
+	if notification.Kind == KindExtended || notification.skips(SinkChat) {
+		return nil
+	}
+	channels := notification.Channels
+	if len(channels) == 0 {
+		channels = []string{""}
+	}
+	var errs []error
+	for _, channel := range channels {
+		body, err := json.Marshal(c.format(notification, channel, time.Now()))
+		if err != nil {
+			return err
+		}
+		err = post(ctx, c.Client, c.URL, map[string]string{"Content-Type": "application/json"}, body, c.Retries, c.RetryDelay)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// format renders the chat message for the notification as of now
+func (c *Chat) format(notification Notification, channel string, now time.Time) chatMessage {
+	title := c.Templates.title(notification, now)
+	namespaces := strings.Join(notification.Namespaces, ", ")
+	expiresAt := notification.ExpiresAt.UTC().Format(time.RFC3339)
+	expiresTitle := "Expires at"
+	color := "#e8a317"
+	var extend string
+	switch notification.Kind {
+	case KindDeleted, KindDeletionFailed:
+		expiresTitle = "Expired at"
+		color = "#808080"
+		if notification.Kind == KindDeletionFailed {
+			color = "#d00000"
+		}
+		if lines := notification.Report.lines(); len(lines) > 0 {
+			extend = "• " + strings.Join(lines, "\n• ")
+		}
+	default:
+		extend = "To extend the environment run:\n```\n" + ExtendCommand(notification.Namespaces) + "\n```"
+	}
+	if body, ok := c.Templates.render(TemplateBody, notification, now); ok {
+		extend = body
+	}
+	message := chatMessage{Channel: channel, Text: title}
+	if c.Flavor == FlavorMattermost {
+		message.Attachments = []map[string]any{{
+			"fallback": title,
+			"color":    color,
+			"title":    title,
+			"fields": []map[string]any{
+				{"title": "Namespaces", "value": namespaces, "short": false},
+				{"title": expiresTitle, "value": expiresAt, "short": true},
+			},
+			"text": extend,
+		}}
+		return message
+	}
+	message.Blocks = []map[string]any{
+		{"type": "header", "text": map[string]any{"type": "plain_text", "text": title}},
+		{"type": "section", "fields": []map[string]any{
+			{"type": "mrkdwn", "text": "*Namespaces*\n" + namespaces},
+			{"type": "mrkdwn", "text": "*" + expiresTitle + "*\n" + expiresAt},
+		}},
+	}
+	if extend != "" {
+		message.Blocks = append(message.Blocks, map[string]any{"type": "section", "text": map[string]any{"type": "mrkdwn", "text": extend}})
+	}
+	return message
+}
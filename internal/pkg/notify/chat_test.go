@@ -0,0 +1,108 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestChatFormat(t *testing.T) {
+	now := time.Date(2026, 10, 16, 16, 30, 0, 0, time.UTC)
+	notification := Notification{
+		Kind:       KindExpiring,
+		Env:        "preview-app",
+		Namespaces: []string{"preview-app-api", "preview-app-db"},
+		ExpiresAt:  now.Add(90 * time.Minute),
+		Factor:     0.9,
+	}
+	command := ExtendCommand(notification.Namespaces)
+
+	t.Run("slack block kit", func(t *testing.T) {
+		message := (&Chat{Flavor: FlavorSlack}).format(notification, "#team-a", now)
+		if message.Channel != "#team-a" || message.Text != "Environment preview-app expires in 1h30m" {
+			t.Errorf("Unexpected message: %+v", message)
+		}
+		if len(message.Blocks) != 3 || len(message.Attachments) != 0 {
+			t.Fatalf("Expected 3 blocks and no attachments, got %+v", message)
+		}
+		encoded, _ := json.Marshal(message)
+		for _, expected := range []string{"preview-app-api, preview-app-db", "2026-10-16T18:00:00Z", `"type":"header"`} {
+			if !strings.Contains(string(encoded), expected) {
+				t.Errorf("Expected message to contain %q, got %s", expected, encoded)
+			}
+		}
+		if !strings.Contains(message.Blocks[2]["text"].(map[string]any)["text"].(string), command) {
+			t.Errorf("Expected extend command in message, got %+v", message.Blocks[2])
+		}
+	})
+
+	t.Run("deleted env has no extend command", func(t *testing.T) {
+		deleted := notification
+		deleted.Kind = KindDeleted
+		message := (&Chat{Flavor: FlavorSlack}).format(deleted, "", now)
+		if message.Text != "Environment preview-app was deleted" || len(message.Blocks) != 2 {
+			t.Errorf("Unexpected deleted message: %+v", message)
+		}
+	})
+
+	t.Run("mattermost attachments", func(t *testing.T) {
+		message := (&Chat{Flavor: FlavorMattermost}).format(notification, "", now)
+		if len(message.Attachments) != 1 || len(message.Blocks) != 0 {
+			t.Fatalf("Expected 1 attachment and no blocks, got %+v", message)
+		}
+		if !strings.Contains(message.Attachments[0]["text"].(string), command) {
+			t.Errorf("Expected extend command in attachment, got %+v", message.Attachments[0])
+		}
+		encoded, _ := json.Marshal(message)
+		if strings.Contains(string(encoded), `"channel"`) {
+			t.Errorf("Expected default channel to be omitted, got %s", encoded)
+		}
+	})
+}
+
+func TestChatNotify(t *testing.T) {
+	var mu sync.Mutex
+	var channels []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var message chatMessage
+		if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+			t.Errorf("Failed to decode message: %v", err)
+		}
+		mu.Lock()
+		channels = append(channels, message.Channel)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	chat := NewChat(server.URL, FlavorSlack, time.Second, 0, 0)
+	notification := Notification{
+		Kind:       KindExpiring,
+		Env:        "env1",
+		Namespaces: []string{"ns1"},
+		ExpiresAt:  time.Now().Add(time.Hour),
+		Channels:   []string{"#team-a", "#team-b"},
+	}
+	if err := chat.Notify(context.Background(), notification); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(channels) != 2 || channels[0] != "#team-a" || channels[1] != "#team-b" {
+		t.Errorf("Expected one message per channel, got %v", channels)
+	}
+
+	notification.Kind = KindExtended
+	if err := chat.Notify(context.Background(), notification); err != nil || len(channels) != 2 {
+		t.Errorf("Expected extended notification to be skipped, got %v and %v", err, channels)
+	}
+}
+
+func TestExtendCommand(t *testing.T) {
+	expected := `kubectl annotate namespace ns1 ns2 kelm.riftonix.io/updateTimestamp="$(date -u +%Y-%m-%dT%H:%M:%SZ)" --overwrite`
+	if command := ExtendCommand([]string{"ns1", "ns2"}); command != expected {
+		t.Errorf("ExtendCommand() = %q, want %q", command, expected)
+	}
+}
@@ -0,0 +1,107 @@
+package notify
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// CloudEvents HTTP content modes
+const (
+	ModeBinary     = "binary"
+	ModeStructured = "structured"
+)
+
+// CloudEvent types of the env lifecycle
+const (
+	EventRegistered     = "io.riftonix.kelm.env.registered"
+	EventExtended       = "io.riftonix.kelm.env.extended"
+	EventWarning        = "io.riftonix.kelm.env.warning"
+	EventExpired        = "io.riftonix.kelm.env.expired"
+	EventDeleted        = "io.riftonix.kelm.env.deleted"
+	EventDeletionFailed = "io.riftonix.kelm.env.deletion_failed"
+)
+
+const cloudEventsSpecVersion = "1.0"
+
+// CloudEvent - one env lifecycle event, Data is sent as JSON
+type CloudEvent struct {
+	Type    string
+	Subject string
+	Time    time.Time
+	Data    any
+}
+
+// CloudEvents posts CloudEvents v1.0 over HTTP in binary or structured content mode
+type CloudEvents struct {
+	URL        string
+	Source     string
+	Mode       string
+	Client     *http.Client
+	Retries    int
+	RetryDelay time.Duration
+}
+
+// structuredEvent - JSON event format used by the structured content mode
+type structuredEvent struct {
+	SpecVersion     string    `json:"specversion"`
+	ID              string    `json:"id"`
+	Source          string    `json:"source"`
+	Type            string    `json:"type"`
+	Subject         string    `json:"subject,omitempty"`
+	Time            time.Time `json:"time"`
+	DataContentType string    `json:"datacontenttype"`
+	Data            any       `json:"data"`
+}
+
+func NewCloudEvents(url string, source string, mode string, timeout time.Duration, retries int, retryDelay time.Duration) *CloudEvents {
+	return &CloudEvents{
+		URL:        url,
+		Source:     source,
+		Mode:       mode,
+		Client:     &http.Client{Timeout: timeout},
+		Retries:    retries,
+		RetryDelay: retryDelay,
+	}
+}
+
+// Emit sends the event, retries reuse the event id so sinks can deduplicate
+func (c *CloudEvents) Emit(ctx context.Context, event CloudEvent) error {
+	headers, body, err := c.format(event, rand.Text())
+	if err != nil {
+		return err
+	}
+	return post(ctx, c.Client, c.URL, headers, body, c.Retries, c.RetryDelay)
+}
+
+// format returns the HTTP headers and body of the event in the configured content mode
+func (c *CloudEvents) format(event CloudEvent, id string) (map[string]string, []byte, error) {
+	if c.Mode == ModeStructured {
+		body, err := json.Marshal(structuredEvent{
+			SpecVersion:     cloudEventsSpecVersion,
+			ID:              id,
+			Source:          c.Source,
+			Type:            event.Type,
+			Subject:         event.Subject,
+			Time:            event.Time.UTC(),
+			DataContentType: "application/json",
+			Data:            event.Data,
+		})
+		return map[string]string{"Content-Type": "application/cloudevents+json"}, body, err
+	}
+	body, err := json.Marshal(event.Data)
+	headers := map[string]string{
+		"Content-Type":   "application/json",
+		"ce-specversion": cloudEventsSpecVersion,
+		"ce-id":          id,
+		"ce-source":      c.Source,
+		"ce-type":        event.Type,
+		"ce-time":        event.Time.UTC().Format(time.RFC3339Nano),
+	}
+	if event.Subject != "" {
+		headers["ce-subject"] = event.Subject
+	}
+	return headers, body, err
+}
@@ -0,0 +1,78 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCloudEventsEmit(t *testing.T) {
+	eventTime := time.Date(2026, 10, 16, 18, 0, 0, 0, time.UTC)
+	event := CloudEvent{
+		Type:    EventDeleted,
+		Subject: "env1",
+		Time:    eventTime,
+		Data:    map[string]string{"env": "env1"},
+	}
+
+	t.Run("binary mode", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			expected := map[string]string{
+				"Content-Type":   "application/json",
+				"Ce-Specversion": "1.0",
+				"Ce-Source":      "kelm",
+				"Ce-Type":        EventDeleted,
+				"Ce-Subject":     "env1",
+				"Ce-Time":        "2026-10-16T18:00:00Z",
+			}
+			for header, value := range expected {
+				if r.Header.Get(header) != value {
+					t.Errorf("Expected header %s=%q, got %q", header, value, r.Header.Get(header))
+				}
+			}
+			if r.Header.Get("Ce-Id") == "" {
+				t.Error("Expected ce-id header")
+			}
+			var data map[string]string
+			if err := json.NewDecoder(r.Body).Decode(&data); err != nil || data["env"] != "env1" {
+				t.Errorf("Expected data as body, got %v (%v)", data, err)
+			}
+		}))
+		defer server.Close()
+
+		emitter := NewCloudEvents(server.URL, "kelm", ModeBinary, time.Second, 0, 0)
+		if err := emitter.Emit(context.Background(), event); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	})
+
+	t.Run("structured mode", func(t *testing.T) {
+		var received structuredEvent
+		var data map[string]string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Content-Type") != "application/cloudevents+json" {
+				t.Errorf("Unexpected Content-Type %q", r.Header.Get("Content-Type"))
+			}
+			received.Data = &data
+			if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+				t.Errorf("Failed to decode event: %v", err)
+			}
+		}))
+		defer server.Close()
+
+		emitter := NewCloudEvents(server.URL, "kelm", ModeStructured, time.Second, 0, 0)
+		if err := emitter.Emit(context.Background(), event); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if received.SpecVersion != "1.0" || received.ID == "" || received.Source != "kelm" || received.Type != EventDeleted ||
+			received.Subject != "env1" || !received.Time.Equal(eventTime) || received.DataContentType != "application/json" {
+			t.Errorf("Unexpected event: %+v", received)
+		}
+		if data["env"] != "env1" {
+			t.Errorf("Unexpected data: %v", data)
+		}
+	})
+}
@@ -0,0 +1,208 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"html"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// Email sends notifications to env owners over SMTP
+type Email struct {
+	Addr     string // host:port
+	From     string
+	Username string
+	Password string
+	// StartTLS requires the server to upgrade the connection before authentication
+	StartTLS  bool
+	TLSConfig *tls.Config
+	Timeout   time.Duration
+	// Optional title, body and html templates
+	Templates *Templates
+}
+
+func NewEmail(addr string, from string, username string, password string, startTLS bool, timeout time.Duration) *Email {
+	return &Email{
+		Addr:     addr,
+		From:     from,
+		Username: username,
+		Password: password,
+		StartTLS: startTLS,
+		Timeout:  timeout,
+	}
+}
+
+// Notify sends one message to every env owner, notifications without owners are skipped
+func (e *Email) Notify(ctx context.Context, notification Notification) error {
+	if len(notification.Owners) == 0 || notification.Kind == KindExtended || notification.skips(SinkEmail) {
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); e.Timeout > 0 && (!ok || time.Until(deadline) > e.Timeout) {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.Timeout)
+		defer cancel()
+	}
+	message, err := e.format(notification, time.Now())
+	if err != nil {
+		return err
+	}
+	return e.send(ctx, notification.Owners, message)
+}
+
+func (e *Email) send(ctx context.Context, recipients []string, message []byte) error {
+	host, _, err := net.SplitHostPort(e.Addr)
+	if err != nil {
+		return fmt.Errorf("smtp address %q: %w", e.Addr, err)
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", e.Addr)
+	if err != nil {
+		return err
+	}
+	// Bound the whole conversation, net/smtp has no context support
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+	if e.StartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return errors.New("smtp server does not support STARTTLS")
+		}
+		tlsConfig := e.TLSConfig
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{ServerName: host}
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+	if e.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", e.Username, e.Password, host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(e.From); err != nil {
+		return err
+	}
+	for _, recipient := range recipients {
+		if err := client.Rcpt(recipient); err != nil {
+			return fmt.Errorf("recipient %s: %w", recipient, err)
+		}
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(message); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// format renders a multipart/alternative message with plain text and HTML bodies
+func (e *Email) format(notification Notification, now time.Time) ([]byte, error) {
+	subject := e.Templates.title(notification, now)
+	textBody, htmlContent := plainTextBody(notification, subject), htmlBody(notification, subject)
+	if rendered, ok := e.Templates.render(TemplateBody, notification, now); ok {
+		textBody = rendered
+		htmlContent = "<html><body>\n<pre>" + html.EscapeString(rendered) + "</pre>\n</body></html>\n"
+	}
+	if rendered, ok := e.Templates.render(TemplateHTML, notification, now); ok {
+		htmlContent = rendered
+	}
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	for _, part := range []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=utf-8", textBody},
+		{"text/html; charset=utf-8", htmlContent},
+	} {
+		writer, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		encoder := quotedprintable.NewWriter(writer)
+		if _, err := encoder.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	var message bytes.Buffer
+	headers := [][2]string{
+		{"From", e.From},
+		{"To", strings.Join(notification.Owners, ", ")},
+		{"Subject", mime.QEncoding.Encode("utf-8", subject)},
+		{"Date", now.Format(time.RFC1123Z)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "multipart/alternative; boundary=" + parts.Boundary()},
+	}
+	for _, header := range headers {
+		fmt.Fprintf(&message, "%s: %s\r\n", header[0], header[1])
+	}
+	message.WriteString("\r\n")
+	message.Write(body.Bytes())
+	return message.Bytes(), nil
+}
+
+func plainTextBody(notification Notification, subject string) string {
+	var body strings.Builder
+	fmt.Fprintf(&body, "%s.\n\nNamespaces: %s\n", subject, strings.Join(notification.Namespaces, ", "))
+	if notification.Kind == KindDeleted || notification.Kind == KindDeletionFailed {
+		fmt.Fprintf(&body, "Expired at: %s\n", notification.ExpiresAt.UTC().Format(time.RFC3339))
+		if lines := notification.Report.lines(); len(lines) > 0 {
+			fmt.Fprintf(&body, "\nTeardown:\n\n  %s\n", strings.Join(lines, "\n  "))
+		}
+		return body.String()
+	}
+	fmt.Fprintf(&body, "Expires at: %s\n\nTo extend the environment run:\n\n  %s\n", notification.ExpiresAt.UTC().Format(time.RFC3339), ExtendCommand(notification.Namespaces))
+	return body.String()
+}
+
+func htmlBody(notification Notification, subject string) string {
+	var body strings.Builder
+	fmt.Fprintf(&body, "<html><body>\n<h2>%s</h2>\n<p><b>Namespaces:</b> %s<br>\n", html.EscapeString(subject), html.EscapeString(strings.Join(notification.Namespaces, ", ")))
+	expiresAt := html.EscapeString(notification.ExpiresAt.UTC().Format(time.RFC3339))
+	if notification.Kind == KindDeleted || notification.Kind == KindDeletionFailed {
+		fmt.Fprintf(&body, "<b>Expired at:</b> %s</p>\n", expiresAt)
+		if lines := notification.Report.lines(); len(lines) > 0 {
+			body.WriteString("<p><b>Teardown:</b></p>\n<ul>\n")
+			for _, line := range lines {
+				fmt.Fprintf(&body, "<li>%s</li>\n", html.EscapeString(line))
+			}
+			body.WriteString("</ul>\n")
+		}
+		body.WriteString("</body></html>\n")
+		return body.String()
+	}
+	fmt.Fprintf(&body, "<b>Expires at:</b> %s</p>\n<p>To extend the environment run:</p>\n<pre>%s</pre>\n</body></html>\n", expiresAt, html.EscapeString(ExtendCommand(notification.Namespaces)))
+	return body.String()
+}
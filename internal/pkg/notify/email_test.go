@@ -0,0 +1,208 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type smtpMessage struct {
+	auth string
+	from string
+	to   []string
+	data string
+}
+
+// smtpStandIn is a minimal in-process SMTP server that accepts every message
+type smtpStandIn struct {
+	listener net.Listener
+	mu       sync.Mutex
+	messages []smtpMessage
+}
+
+func newSMTPStandIn(t *testing.T) *smtpStandIn {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	server := &smtpStandIn{listener: listener}
+	t.Cleanup(func() { listener.Close() })
+	go server.serve()
+	return server
+}
+
+func (s *smtpStandIn) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *smtpStandIn) handle(conn net.Conn) {
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+	var message smtpMessage
+	_ = text.PrintfLine("220 localhost ESMTP stand-in")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch command {
+		case "EHLO", "HELO":
+			_ = text.PrintfLine("250-localhost")
+			_ = text.PrintfLine("250 AUTH PLAIN")
+		case "AUTH":
+			decoded, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(line, "AUTH PLAIN "))
+			message.auth = string(decoded)
+			_ = text.PrintfLine("235 Authenticated")
+		case "MAIL":
+			message.from = strings.Trim(strings.TrimPrefix(line, "MAIL FROM:"), "<>")
+			_ = text.PrintfLine("250 OK")
+		case "RCPT":
+			message.to = append(message.to, strings.Trim(strings.TrimPrefix(line, "RCPT TO:"), "<>"))
+			_ = text.PrintfLine("250 OK")
+		case "DATA":
+			_ = text.PrintfLine("354 Go ahead")
+			data, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			message.data = string(data)
+			s.mu.Lock()
+			s.messages = append(s.messages, message)
+			s.mu.Unlock()
+			message = smtpMessage{}
+			_ = text.PrintfLine("250 Queued")
+		case "QUIT":
+			_ = text.PrintfLine("221 Bye")
+			return
+		default:
+			_ = text.PrintfLine("502 Not implemented")
+		}
+	}
+}
+
+func (s *smtpStandIn) received() []smtpMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]smtpMessage(nil), s.messages...)
+}
+
+// readBodies returns decoded parts of a multipart/alternative message by content type
+func readBodies(t *testing.T, data string) (*mail.Message, map[string]string) {
+	message, err := mail.ReadMessage(bufio.NewReader(strings.NewReader(data)))
+	if err != nil {
+		t.Fatalf("Failed to parse message: %v", err)
+	}
+	mediaType, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Expected multipart/alternative, got %q: %v", mediaType, err)
+	}
+	bodies := make(map[string]string)
+	reader := multipart.NewReader(message.Body, params["boundary"])
+	for {
+		part, err := reader.NextRawPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to read part: %v", err)
+		}
+		content, _ := io.ReadAll(quotedprintable.NewReader(part))
+		contentType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		bodies[contentType] = string(content)
+	}
+	return message, bodies
+}
+
+func TestEmailNotify(t *testing.T) {
+	notification := Notification{
+		Kind:       KindExpiring,
+		Env:        "preview-app",
+		Namespaces: []string{"preview-app-api", "preview-app-db"},
+		ExpiresAt:  time.Now().Add(2 * time.Hour),
+		Owners:     []string{"alice@example.com", "bob@example.com"},
+	}
+
+	t.Run("sends multipart message to owners", func(t *testing.T) {
+		server := newSMTPStandIn(t)
+		email := NewEmail(server.listener.Addr().String(), "kelm@example.com", "kelm", "secret", false, time.Second)
+		if err := email.Notify(context.Background(), notification); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		received := server.received()
+		if len(received) != 1 {
+			t.Fatalf("Expected 1 message, got %d", len(received))
+		}
+		if received[0].from != "kelm@example.com" || len(received[0].to) != 2 || received[0].auth != "\x00kelm\x00secret" {
+			t.Errorf("Unexpected envelope: %+v", received[0])
+		}
+		message, bodies := readBodies(t, received[0].data)
+		if subject := message.Header.Get("Subject"); subject != "Environment preview-app expires in 2h" {
+			t.Errorf("Unexpected subject %q", subject)
+		}
+		command := ExtendCommand(notification.Namespaces)
+		if !strings.Contains(bodies["text/plain"], command) {
+			t.Errorf("Expected extend command in plain text, got %q", bodies["text/plain"])
+		}
+		if !strings.Contains(bodies["text/html"], "<pre>kubectl annotate namespace preview-app-api preview-app-db") || !strings.Contains(bodies["text/html"], "&#34;$(date") {
+			t.Errorf("Expected escaped extend command in HTML, got %q", bodies["text/html"])
+		}
+	})
+
+	t.Run("deleted env", func(t *testing.T) {
+		server := newSMTPStandIn(t)
+		deleted := notification
+		deleted.Kind = KindDeleted
+		if err := NewEmail(server.listener.Addr().String(), "kelm@example.com", "", "", false, time.Second).Notify(context.Background(), deleted); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		received := server.received()
+		if len(received) != 1 || received[0].auth != "" {
+			t.Fatalf("Expected 1 message without auth, got %+v", received)
+		}
+		message, bodies := readBodies(t, received[0].data)
+		if subject := message.Header.Get("Subject"); subject != "Environment preview-app was deleted" {
+			t.Errorf("Unexpected subject %q", subject)
+		}
+		if strings.Contains(bodies["text/plain"], "kubectl") {
+			t.Errorf("Expected no extend command for deleted env, got %q", bodies["text/plain"])
+		}
+	})
+
+	t.Run("requires STARTTLS", func(t *testing.T) {
+		server := newSMTPStandIn(t)
+		email := NewEmail(server.listener.Addr().String(), "kelm@example.com", "kelm", "secret", true, time.Second)
+		if err := email.Notify(context.Background(), notification); err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+			t.Errorf("Expected STARTTLS error, got %v", err)
+		}
+		if len(server.received()) != 0 {
+			t.Error("Expected no message without STARTTLS")
+		}
+	})
+
+	t.Run("skips env without owners", func(t *testing.T) {
+		email := NewEmail("127.0.0.1:1", "kelm@example.com", "", "", false, time.Second)
+		withoutOwners := notification
+		withoutOwners.Owners = nil
+		if err := email.Notify(context.Background(), withoutOwners); err != nil {
+			t.Errorf("Expected no error without owners, got %v", err)
+		}
+	})
+}
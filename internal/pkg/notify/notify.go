@@ -0,0 +1,98 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"kelm/internal/pkg/timer"
+)
+
+// Notification kinds
+const (
+	KindExpiring = "expiring"
+	// The env got a later deadline after an expiring notification
+	KindExtended = "extended"
+	KindDeleted  = "deleted"
+	// Some env namespaces were not deleted, the deletion is retried
+	KindDeletionFailed = "deletion_failed"
+)
+
+// Sink names for per-env routing
+const (
+	SinkWebhook      = "webhook"
+	SinkChat         = "chat"
+	SinkEmail        = "email"
+	SinkAlertmanager = "alertmanager"
+)
+
+// Notification - information about env lifecycle sent to the notifiers
+type Notification struct {
+	Kind       string    `json:"kind"`
+	Env        string    `json:"env"`
+	Namespaces []string  `json:"namespaces"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	// Fraction of the env lifetime that has elapsed, set for KindExpiring
+	Factor float64 `json:"factor"`
+	// Chat channels chosen by the env, empty means the webhook default channel
+	Channels []string `json:"channels,omitempty"`
+	// Owner email addresses merged across env namespaces
+	Owners []string `json:"owners,omitempty"`
+	// Sinks chosen by the env, empty means every configured sink
+	Sinks []string `json:"sinks,omitempty"`
+	// Deletion outcome, set for KindDeleted and KindDeletionFailed
+	Report *TeardownReport `json:"report,omitempty"`
+	// Full env for the notification templates
+	EnvDetails any `json:"-"`
+}
+
+// Notifier delivers notifications to one destination
+type Notifier interface {
+	Notify(ctx context.Context, notification Notification) error
+}
+
+// Notifiers delivers notifications to every notifier in the list
+type Notifiers []Notifier
+
+func (n Notifiers) Notify(ctx context.Context, notification Notification) error {
+	var errs []error
+	for _, notifier := range n {
+		if err := notifier.Notify(ctx, notification); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// skips reports whether the env routed the notification away from the sink
+func (n Notification) skips(sink string) bool {
+	return len(n.Sinks) > 0 && !slices.Contains(n.Sinks, sink)
+}
+
+// ExtendCommand returns the kubectl command that extends the env lifetime
+func ExtendCommand(namespaces []string) string {
+	return fmt.Sprintf(
+		`kubectl annotate namespace %s kelm.riftonix.io/updateTimestamp="$(date -u +%%Y-%%m-%%dT%%H:%%M:%%SZ)" --overwrite`,
+		strings.Join(namespaces, " "),
+	)
+}
+
+// title returns one-line summary of the notification as of now
+func title(notification Notification, now time.Time) string {
+	switch notification.Kind {
+	case KindDeleted:
+		return fmt.Sprintf("Environment %s was deleted", notification.Env)
+	case KindDeletionFailed:
+		if notification.Report != nil && !notification.Report.RetryAt.IsZero() {
+			return fmt.Sprintf("Environment %s is stuck in deletion, retry at %s", notification.Env, notification.Report.RetryAt.UTC().Format(time.RFC3339))
+		}
+		return fmt.Sprintf("Environment %s is stuck in deletion", notification.Env)
+	case KindExtended:
+		return fmt.Sprintf("Environment %s was extended until %s", notification.Env, notification.ExpiresAt.UTC().Format(time.RFC3339))
+	}
+	remaining := max(notification.ExpiresAt.Sub(now).Round(time.Minute), 0)
+	return fmt.Sprintf("Environment %s expires in %s", notification.Env, timer.FormatDuration(remaining))
+}
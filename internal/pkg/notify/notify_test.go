@@ -0,0 +1,57 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type recordingNotifier struct {
+	calls int
+	err   error
+}
+
+func (r *recordingNotifier) Notify(ctx context.Context, notification Notification) error {
+	r.calls++
+	return r.err
+}
+
+func TestNotifiers(t *testing.T) {
+	failing := &recordingNotifier{err: errors.New("unreachable")}
+	working := &recordingNotifier{}
+	err := Notifiers{failing, working}.Notify(context.Background(), Notification{Env: "env1"})
+	if !errors.Is(err, failing.err) {
+		t.Errorf("Expected error of the failing notifier, got %v", err)
+	}
+	if failing.calls != 1 || working.calls != 1 {
+		t.Errorf("Expected every notifier to be called once, got %d and %d", failing.calls, working.calls)
+	}
+}
+
+func TestNotificationSinks(t *testing.T) {
+	// Nothing listens there, so every notifier that does not skip returns an error
+	const unreachable = "http://127.0.0.1:1"
+	notifiers := map[string]Notifier{
+		SinkWebhook:      NewWebhook(unreachable, time.Second, 0, 0),
+		SinkChat:         NewChat(unreachable, FlavorSlack, time.Second, 0, 0),
+		SinkEmail:        NewEmail("127.0.0.1:1", "kelm@example.com", "", "", false, time.Second),
+		SinkAlertmanager: NewAlertmanager(unreachable, time.Second, 0, 0),
+	}
+	for sink, notifier := range notifiers {
+		t.Run(sink, func(t *testing.T) {
+			notification := Notification{Kind: KindExpiring, Env: "env1", Owners: []string{"alice@example.com"}}
+			if err := notifier.Notify(context.Background(), notification); err == nil {
+				t.Error("Expected delivery attempt without routing")
+			}
+			notification.Sinks = []string{sink}
+			if err := notifier.Notify(context.Background(), notification); err == nil {
+				t.Error("Expected delivery attempt for its own sink")
+			}
+			notification.Sinks = []string{"other"}
+			if err := notifier.Notify(context.Background(), notification); err != nil {
+				t.Errorf("Expected notification routed elsewhere to be skipped, got %v", err)
+			}
+		})
+	}
+}
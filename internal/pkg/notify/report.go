@@ -0,0 +1,70 @@
+package notify
+
+import (
+	"fmt"
+	"time"
+)
+
+// Zarf removal and prune states
+const (
+	ZarfRemoved  = "removed"
+	ZarfNotFound = "not-found"
+	ZarfPruned   = "pruned"
+	ZarfFailed   = "error"
+)
+
+// TeardownReport - outcome of env deletion, set for KindDeleted and KindDeletionFailed
+type TeardownReport struct {
+	Namespaces []NamespaceReport `json:"namespaces"`
+	Zarf       *ZarfReport       `json:"zarf,omitempty"`
+	// Next deletion attempt, zero when the env is gone
+	RetryAt time.Time `json:"retryAt,omitzero"`
+}
+
+// NamespaceReport - deletion result of one namespace
+type NamespaceReport struct {
+	Namespace string `json:"namespace"`
+	State     string `json:"state"` // "deleted", "force-deleted", "not-found", "timeout", "error"
+	Duration  string `json:"duration"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ZarfReport - Zarf package removal and registry prune outcome
+type ZarfReport struct {
+	Package      string `json:"package"`
+	Removal      string `json:"removal"`
+	RemovalError string `json:"removalError,omitempty"`
+	Prune        string `json:"prune"`
+	PruneError   string `json:"pruneError,omitempty"`
+}
+
+// lines renders the report as plain text lines, one per namespace, Zarf step and retry
+func (r *TeardownReport) lines() []string {
+	if r == nil {
+		return nil
+	}
+	lines := make([]string, 0, len(r.Namespaces)+3)
+	for _, ns := range r.Namespaces {
+		line := fmt.Sprintf("%s: %s in %s", ns.Namespace, ns.State, ns.Duration)
+		if ns.Error != "" {
+			line += ": " + ns.Error
+		}
+		lines = append(lines, line)
+	}
+	if r.Zarf != nil {
+		line := fmt.Sprintf("Zarf package %s: %s", r.Zarf.Package, r.Zarf.Removal)
+		if r.Zarf.RemovalError != "" {
+			line += ": " + r.Zarf.RemovalError
+		}
+		lines = append(lines, line)
+		line = "Zarf registry prune: " + r.Zarf.Prune
+		if r.Zarf.PruneError != "" {
+			line += ": " + r.Zarf.PruneError
+		}
+		lines = append(lines, line)
+	}
+	if !r.RetryAt.IsZero() {
+		lines = append(lines, "Retry at "+r.RetryAt.UTC().Format(time.RFC3339))
+	}
+	return lines
+}
@@ -0,0 +1,57 @@
+package notify
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTeardownReport(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	report := &TeardownReport{
+		Namespaces: []NamespaceReport{
+			{Namespace: "ns1", State: "force-deleted", Duration: "1m2s"},
+			{Namespace: "ns2", State: "error", Duration: "3s", Error: "remove finalizers: forbidden"},
+		},
+		Zarf:    &ZarfReport{Package: "preview", Removal: ZarfRemoved, Prune: ZarfFailed, PruneError: "registry unavailable"},
+		RetryAt: now.Add(time.Minute),
+	}
+	expected := []string{
+		"ns1: force-deleted in 1m2s",
+		"ns2: error in 3s: remove finalizers: forbidden",
+		"Zarf package preview: removed",
+		"Zarf registry prune: error: registry unavailable",
+		"Retry at 2026-10-16T12:01:00Z",
+	}
+	if lines := report.lines(); !slices.Equal(lines, expected) {
+		t.Errorf("Expected %q, got %q", expected, lines)
+	}
+	if lines := (*TeardownReport)(nil).lines(); lines != nil {
+		t.Errorf("Expected no lines without report, got %q", lines)
+	}
+
+	notification := Notification{Kind: KindDeletionFailed, Env: "env1", Namespaces: []string{"ns1", "ns2"}, ExpiresAt: now, Report: report}
+	if summary := title(notification, now); summary != "Environment env1 is stuck in deletion, retry at 2026-10-16T12:01:00Z" {
+		t.Errorf("Unexpected title %q", summary)
+	}
+
+	message := (&Chat{Flavor: FlavorSlack}).format(notification, "", now)
+	if text := message.Blocks[2]["text"].(map[string]any)["text"].(string); !strings.Contains(text, "• ns2: error in 3s") {
+		t.Errorf("Expected report in chat message, got %q", text)
+	}
+	if body := plainTextBody(notification, "subject"); !strings.Contains(body, "Teardown:") || !strings.Contains(body, "Retry at") {
+		t.Errorf("Expected report in email, got %q", body)
+	}
+	if body := htmlBody(notification, "subject"); !strings.Contains(body, "<li>Zarf package preview: removed</li>") {
+		t.Errorf("Expected report in email HTML, got %q", body)
+	}
+
+	stuck := NewAlertmanager("http://alertmanager:9093", time.Second, 0, 0).format(notification, now)
+	if !stuck.StartsAt.Equal(now) || !stuck.EndsAt.Equal(report.RetryAt.Add(deletionAttemptTimeout)) {
+		t.Errorf("Expected stuck alert to fire through the retry, got %v - %v", stuck.StartsAt, stuck.EndsAt)
+	}
+	if !strings.Contains(stuck.Annotations["description"], "ns2: error") {
+		t.Errorf("Expected report in alert description, got %v", stuck.Annotations)
+	}
+}
@@ -0,0 +1,123 @@
+package notify
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"kelm/internal/pkg/timer"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Template keys, every key is optional and a missing one keeps the built-in message
+const (
+	// One-line summary: chat header, email subject and alert summary
+	TemplateTitle = "title"
+	// Plain text message: chat text, email text part and alert description
+	TemplateBody = "body"
+	// Email HTML part, the plain text body in <pre> is used without it
+	TemplateHTML = "html"
+	// Whole webhook request body
+	TemplateWebhook = "webhook"
+)
+
+var templateKeys = []string{TemplateTitle, TemplateBody, TemplateHTML, TemplateWebhook}
+
+// TemplateData is passed to the notification templates
+type TemplateData struct {
+	Notification
+	// Full env the notification is about, shadows Notification.Env
+	Env           any
+	ExtendCommand string
+	Now           time.Time
+}
+
+var templateFuncs = template.FuncMap{
+	"join":           strings.Join,
+	"formatDuration": timer.FormatDuration,
+	// until returns time left until t, rounded to minutes
+	"until": func(t time.Time) time.Duration {
+		return max(time.Until(t).Round(time.Minute), 0)
+	},
+	// timeLeft formats time left until t, like "1d2h30m"
+	"timeLeft": func(t time.Time) string {
+		return timer.FormatDuration(max(time.Until(t).Round(time.Minute), 0))
+	},
+}
+
+// Templates holds user-defined notification templates, the zero value has none.
+// Templates can be replaced at any time, notifiers render the latest loaded set.
+type Templates struct {
+	mu        sync.RWMutex
+	templates map[string]*template.Template
+}
+
+// Load parses templates by key and test-renders them with every sample notification.
+// On error the previously loaded templates stay in use.
+func (t *Templates) Load(sources map[string]string, samples []Notification) error {
+	parsed := make(map[string]*template.Template, len(sources))
+	for key, source := range sources {
+		if !slices.Contains(templateKeys, key) {
+			return fmt.Errorf("unknown template %q, expected one of %s", key, strings.Join(templateKeys, ", "))
+		}
+		tmpl, err := template.New(key).Funcs(templateFuncs).Option("missingkey=error").Parse(source)
+		if err != nil {
+			return err
+		}
+		for _, sample := range samples {
+			if err := tmpl.Execute(new(strings.Builder), newTemplateData(sample, time.Now())); err != nil {
+				return fmt.Errorf("%s notification: %w", sample.Kind, err)
+			}
+		}
+		parsed[key] = tmpl
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.templates = parsed
+	return nil
+}
+
+func newTemplateData(notification Notification, now time.Time) TemplateData {
+	data := TemplateData{
+		Notification:  notification,
+		Env:           notification.EnvDetails,
+		ExtendCommand: ExtendCommand(notification.Namespaces),
+		Now:           now,
+	}
+	if data.Env == nil {
+		data.Env = notification.Env
+	}
+	return data
+}
+
+// render executes the template by key, ok is false when there is no such template or it failed.
+// Safe to call on nil Templates.
+func (t *Templates) render(key string, notification Notification, now time.Time) (string, bool) {
+	if t == nil {
+		return "", false
+	}
+	t.mu.RLock()
+	tmpl := t.templates[key]
+	t.mu.RUnlock()
+	if tmpl == nil {
+		return "", false
+	}
+	var result strings.Builder
+	if err := tmpl.Execute(&result, newTemplateData(notification, now)); err != nil {
+		logrus.Errorf("Failed to render %s template for env '%s', using the built-in message: %v", key, notification.Env, err)
+		return "", false
+	}
+	return result.String(), true
+}
+
+// title renders the title template or the built-in title
+func (t *Templates) title(notification Notification, now time.Time) string {
+	if result, ok := t.render(TemplateTitle, notification, now); ok {
+		return strings.TrimSpace(result)
+	}
+	return title(notification, now)
+}
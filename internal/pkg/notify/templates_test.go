@@ -0,0 +1,99 @@
+package notify
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type templateEnv struct {
+	Name      string
+	Dashboard string
+}
+
+func TestTemplatesLoad(t *testing.T) {
+	sample := Notification{
+		Kind:       KindExpiring,
+		Env:        "env1",
+		Namespaces: []string{"ns1"},
+		ExpiresAt:  time.Now().Add(time.Hour),
+		EnvDetails: templateEnv{Name: "env1", Dashboard: "https://grafana.example.com/d/env1"},
+	}
+	tests := []struct {
+		name        string
+		sources     map[string]string
+		expectError bool
+	}{
+		{"all keys", map[string]string{
+			TemplateTitle:   "{{ .Env.Name }} expires in {{ timeLeft .ExpiresAt }}",
+			TemplateBody:    "{{ join .Namespaces \", \" }}: {{ .ExtendCommand }}",
+			TemplateHTML:    "<a href=\"{{ .Env.Dashboard | html }}\">dashboard</a>",
+			TemplateWebhook: "{\"text\": {{ printf \"%q\" .Kind }}}",
+		}, false},
+		{"empty", map[string]string{}, false},
+		{"unknown key", map[string]string{"subject": "x"}, true},
+		{"parse error", map[string]string{TemplateTitle: "{{ .Env.Name "}, true},
+		{"unknown field", map[string]string{TemplateTitle: "{{ .Env.Owner }}"}, true},
+		{"unknown function", map[string]string{TemplateBody: "{{ upper .Kind }}"}, true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			var templates Templates
+			err := templates.Load(testCase.sources, []Notification{sample})
+			if testCase.expectError && err == nil {
+				t.Error("Expected error, got nil")
+			}
+			if !testCase.expectError && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestTemplatesRender(t *testing.T) {
+	now := time.Now()
+	notification := Notification{
+		Kind:       KindExpiring,
+		Env:        "env1",
+		Namespaces: []string{"ns1", "ns2"},
+		ExpiresAt:  now.Add(2 * time.Hour),
+		EnvDetails: templateEnv{Name: "env1", Dashboard: "https://grafana.example.com/d/env1"},
+	}
+
+	var empty *Templates
+	if title := empty.title(notification, now); title != "Environment env1 expires in 2h" {
+		t.Errorf("Expected built-in title without templates, got %q", title)
+	}
+
+	templates := &Templates{}
+	err := templates.Load(map[string]string{
+		TemplateTitle: "{{ .Env.Name }} expires in {{ timeLeft .ExpiresAt }}\n",
+		TemplateBody:  "Dashboard: {{ .Env.Dashboard }}\n{{ .ExtendCommand }}",
+	}, []Notification{notification})
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if title := templates.title(notification, now); title != "env1 expires in 2h" {
+		t.Errorf("Unexpected title %q", title)
+	}
+	message := (&Chat{Flavor: FlavorMattermost, Templates: templates}).format(notification, "", now)
+	if message.Text != "env1 expires in 2h" || !strings.HasPrefix(message.Attachments[0]["text"].(string), "Dashboard: https://grafana.example.com/d/env1\nkubectl annotate") {
+		t.Errorf("Expected templated chat message, got %+v", message)
+	}
+
+	// Env without details falls back to the name, the title template fails on .Env.Name
+	notification.EnvDetails = nil
+	if title := templates.title(notification, now); title != "Environment env1 expires in 2h" {
+		t.Errorf("Expected built-in title after render error, got %q", title)
+	}
+
+	// Reloading with a bad template keeps the loaded ones
+	notification.EnvDetails = templateEnv{Name: "env1"}
+	if err := templates.Load(map[string]string{TemplateTitle: "{{"}, nil); err == nil {
+		t.Fatal("Expected parse error")
+	}
+	if _, ok := templates.render(TemplateBody, notification, now); !ok {
+		t.Error("Expected previous templates to stay after failed load")
+	}
+}
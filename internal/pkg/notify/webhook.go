@@ -0,0 +1,118 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Webhook posts notifications as JSON to a generic HTTP endpoint
+type Webhook struct {
+	URL        string
+	Client     *http.Client
+	Retries    int
+	RetryDelay time.Duration
+	// Optional webhook template that replaces the JSON payload
+	Templates *Templates
+}
+
+func NewWebhook(url string, timeout time.Duration, retries int, retryDelay time.Duration) *Webhook {
+	return &Webhook{
+		URL:        url,
+		Client:     &http.Client{Timeout: timeout},
+		Retries:    retries,
+		RetryDelay: retryDelay,
+	}
+}
+
+func (w *Webhook) Notify(ctx context.Context, notification Notification) error {
+	if notification.skips(SinkWebhook) {
+		return nil
+	}
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+	if rendered, ok := w.Templates.render(TemplateWebhook, notification, time.Now()); ok {
+		body = []byte(rendered)
+	}
+	return post(ctx, w.Client, w.URL, map[string]string{"Content-Type": "application/json"}, body, w.Retries, w.RetryDelay)
+}
+
+// post sends body to endpoint, retrying network errors, 429 and 5xx responses.
+// Webhook URLs often carry tokens, so logs and errors name only the scheme and host.
+func post(
+	ctx context.Context,
+	client *http.Client,
+	endpoint string,
+	headers map[string]string,
+	body []byte,
+	retries int,
+	retryDelay time.Duration,
+) error {
+	var err error
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			logrus.Debugf("Retrying notification to %s (%d/%d) after: %v", redactURL(endpoint), attempt, retries, err)
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("%w, last error: %v", ctx.Err(), err)
+			case <-time.After(retryDelay):
+			}
+		}
+		var retry bool
+		retry, err = postOnce(ctx, client, endpoint, headers, body)
+		if err == nil || !retry {
+			return err
+		}
+	}
+	return err
+}
+
+func postOnce(ctx context.Context, client *http.Client, endpoint string, headers map[string]string, body []byte) (bool, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return false, redactError(err, endpoint)
+	}
+	for key, value := range headers {
+		request.Header.Set(key, value)
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return ctx.Err() == nil, redactError(err, endpoint)
+	}
+	defer response.Body.Close()
+	// Drain the body so the connection can be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, 64*1024))
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return false, nil
+	}
+	retry := response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= 500
+	return retry, fmt.Errorf("POST %s returned %s", redactURL(endpoint), response.Status)
+}
+
+// redactURL drops the path, query and credentials of endpoint, where webhook tokens live
+func redactURL(endpoint string) string {
+	parsed, err := url.Parse(endpoint)
+	if err != nil || parsed.Host == "" {
+		return "<invalid URL>"
+	}
+	return parsed.Scheme + "://" + parsed.Host
+}
+
+// redactError replaces the URL that net/http puts into its errors
+func redactError(err error, endpoint string) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return &url.Error{Op: urlErr.Op, URL: redactURL(endpoint), Err: urlErr.Err}
+	}
+	return err
+}
@@ -0,0 +1,122 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWebhookNotify(t *testing.T) {
+	expiresAt := time.Date(2026, 10, 16, 18, 0, 0, 0, time.UTC)
+	notification := Notification{
+		Kind:       KindExpiring,
+		Env:        "env1",
+		Namespaces: []string{"ns1", "ns2"},
+		ExpiresAt:  expiresAt,
+		Factor:     0.8,
+	}
+
+	t.Run("posts json payload", func(t *testing.T) {
+		var received Notification
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+				t.Errorf("Unexpected request %s with Content-Type %q", r.Method, r.Header.Get("Content-Type"))
+			}
+			if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+				t.Errorf("Failed to decode payload: %v", err)
+			}
+		}))
+		defer server.Close()
+
+		if err := NewWebhook(server.URL, time.Second, 0, 0).Notify(context.Background(), notification); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if received.Env != "env1" || len(received.Namespaces) != 2 || !received.ExpiresAt.Equal(expiresAt) || received.Factor != 0.8 {
+			t.Errorf("Unexpected payload: %+v", received)
+		}
+	})
+
+	t.Run("retries server errors", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) < 3 {
+				w.WriteHeader(http.StatusBadGateway)
+			}
+		}))
+		defer server.Close()
+
+		if err := NewWebhook(server.URL, time.Second, 2, time.Millisecond).Notify(context.Background(), notification); err != nil {
+			t.Fatalf("Expected success on third attempt, got %v", err)
+		}
+		if calls.Load() != 3 {
+			t.Errorf("Expected 3 attempts, got %d", calls.Load())
+		}
+	})
+
+	t.Run("gives up after retries", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		if err := NewWebhook(server.URL, time.Second, 1, time.Millisecond).Notify(context.Background(), notification); err == nil {
+			t.Error("Expected error after retries")
+		}
+		if calls.Load() != 2 {
+			t.Errorf("Expected 2 attempts, got %d", calls.Load())
+		}
+	})
+
+	t.Run("does not retry client errors", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		defer server.Close()
+
+		if err := NewWebhook(server.URL, time.Second, 3, time.Millisecond).Notify(context.Background(), notification); err == nil {
+			t.Error("Expected error for 400 response")
+		}
+		if calls.Load() != 1 {
+			t.Errorf("Expected 1 attempt, got %d", calls.Load())
+		}
+	})
+
+	t.Run("times out", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(200 * time.Millisecond)
+		}))
+		defer server.Close()
+
+		if err := NewWebhook(server.URL, 20*time.Millisecond, 0, 0).Notify(context.Background(), notification); err == nil {
+			t.Error("Expected timeout error")
+		}
+	})
+
+	t.Run("keeps the URL token out of errors", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		endpoint := server.URL + "/hooks/secret-token?key=secret-key"
+
+		err := NewWebhook(endpoint, time.Second, 1, time.Millisecond).Notify(context.Background(), notification)
+		if err == nil || strings.Contains(err.Error(), "secret") || !strings.Contains(err.Error(), server.URL) {
+			t.Errorf("Expected error naming only the host, got %v", err)
+		}
+
+		// Network errors of net/http carry the URL too
+		server.Close()
+		err = NewWebhook(endpoint, time.Second, 0, 0).Notify(context.Background(), notification)
+		if err == nil || strings.Contains(err.Error(), "secret") {
+			t.Errorf("Expected network error without the token, got %v", err)
+		}
+	})
+}
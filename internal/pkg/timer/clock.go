@@ -0,0 +1,187 @@
+package timer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Clock decides which time counts towards a TTL
+type Clock interface {
+	// Add returns the moment when d of counted time has passed since start
+	Add(start time.Time, d time.Duration) time.Time
+	// Between returns counted time between start and end, 0 if end is not after start
+	Between(start, end time.Time) time.Duration
+}
+
+// WallClock counts every second
+type WallClock struct{}
+
+func (WallClock) Add(start time.Time, d time.Duration) time.Time {
+	return start.Add(d)
+}
+
+func (WallClock) Between(start, end time.Time) time.Duration {
+	if !end.After(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
+
+// Upper bound for calendar walks, protects from calendars where every day is a holiday
+const maxCalendarDays = 10 * 366
+
+// BusinessCalendar counts only working hours on working days, holidays excluded
+type BusinessCalendar struct {
+	Weekdays    [7]bool
+	StartMinute int // minutes after midnight
+	EndMinute   int
+	Location    *time.Location
+	Holidays    map[string]bool // dates in "2006-01-02" format
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// ParseBusinessHours parses spec like "Mon-Fri 09:00-18:00 Europe/Berlin".
+// Days accept ranges and lists ("Mon,Wed,Fri"), timezone defaults to UTC.
+// Holidays is a comma-separated list of dates like "2026-12-25,2026-12-26".
+func ParseBusinessHours(spec string, holidays string) (BusinessCalendar, error) {
+	calendar := BusinessCalendar{Location: time.UTC, Holidays: map[string]bool{}}
+	fields := strings.Fields(spec)
+	if len(fields) < 2 || len(fields) > 3 {
+		return calendar, fmt.Errorf("business hours %q must look like 'Mon-Fri 09:00-18:00 Europe/Berlin'", spec)
+	}
+	if err := calendar.parseWeekdays(fields[0]); err != nil {
+		return calendar, err
+	}
+	if err := calendar.parseHours(fields[1]); err != nil {
+		return calendar, err
+	}
+	if len(fields) == 3 {
+		location, err := time.LoadLocation(fields[2])
+		if err != nil {
+			return calendar, fmt.Errorf("business hours timezone %q: %w", fields[2], err)
+		}
+		calendar.Location = location
+	}
+	for _, holiday := range strings.Split(holidays, ",") {
+		holiday = strings.TrimSpace(holiday)
+		if holiday == "" {
+			continue
+		}
+		if _, err := time.Parse(time.DateOnly, holiday); err != nil {
+			return calendar, fmt.Errorf("holiday %q: %w", holiday, err)
+		}
+		calendar.Holidays[holiday] = true
+	}
+	return calendar, nil
+}
+
+func (c *BusinessCalendar) parseWeekdays(spec string) error {
+	for _, part := range strings.Split(strings.ToLower(spec), ",") {
+		from, to, isRange := strings.Cut(part, "-")
+		first, ok := weekdayNames[from]
+		if !ok {
+			return fmt.Errorf("unknown weekday %q", from)
+		}
+		last := first
+		if isRange {
+			if last, ok = weekdayNames[to]; !ok {
+				return fmt.Errorf("unknown weekday %q", to)
+			}
+		}
+		for day := first; ; day = (day + 1) % 7 {
+			c.Weekdays[day] = true
+			if day == last {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func (c *BusinessCalendar) parseHours(spec string) error {
+	from, to, ok := strings.Cut(spec, "-")
+	if !ok {
+		return fmt.Errorf("business hours %q must look like '09:00-18:00'", spec)
+	}
+	start, err := time.Parse("15:04", from)
+	if err != nil {
+		return fmt.Errorf("business hours start %q: %w", from, err)
+	}
+	end, err := time.Parse("15:04", to)
+	if err != nil {
+		return fmt.Errorf("business hours end %q: %w", to, err)
+	}
+	c.StartMinute = start.Hour()*60 + start.Minute()
+	c.EndMinute = end.Hour()*60 + end.Minute()
+	if c.EndMinute <= c.StartMinute {
+		return fmt.Errorf("business hours %q must end after they start", spec)
+	}
+	return nil
+}
+
+// window returns working hours of the day containing t, ok is false for days off
+func (c BusinessCalendar) window(t time.Time) (start time.Time, end time.Time, ok bool) {
+	year, month, day := t.Date()
+	if !c.Weekdays[t.Weekday()] || c.Holidays[t.Format(time.DateOnly)] {
+		return start, end, false
+	}
+	start = time.Date(year, month, day, 0, c.StartMinute, 0, 0, c.Location)
+	end = time.Date(year, month, day, 0, c.EndMinute, 0, 0, c.Location)
+	return start, end, true
+}
+
+func nextDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day+1, 0, 0, 0, 0, t.Location())
+}
+
+func (c BusinessCalendar) Add(start time.Time, d time.Duration) time.Time {
+	if d <= 0 {
+		return start
+	}
+	t := start.In(c.Location)
+	for range maxCalendarDays {
+		dayStart, dayEnd, ok := c.window(t)
+		if ok && t.Before(dayEnd) {
+			from := GetMaxTime(t, dayStart)
+			available := dayEnd.Sub(from)
+			if d <= available {
+				return from.Add(d).In(start.Location())
+			}
+			d -= available
+		}
+		t = nextDay(t)
+	}
+	// No working time found in years, count the rest as wall time
+	return t.Add(d).In(start.Location())
+}
+
+func (c BusinessCalendar) Between(start, end time.Time) time.Duration {
+	var total time.Duration
+	t := start.In(c.Location)
+	for range maxCalendarDays {
+		if !t.Before(end) {
+			break
+		}
+		dayStart, dayEnd, ok := c.window(t)
+		if ok {
+			from := GetMaxTime(t, dayStart)
+			to := GetMinTime(dayEnd, end)
+			if to.After(from) {
+				total += to.Sub(from)
+			}
+		}
+		t = nextDay(t)
+	}
+	return total
+}
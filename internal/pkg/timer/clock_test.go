@@ -0,0 +1,136 @@
+package timer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseBusinessHours(t *testing.T) {
+	tests := []struct {
+		spec        string
+		holidays    string
+		expectError bool
+	}{
+		{"Mon-Fri 09:00-18:00 Europe/Berlin", "", false},
+		{"Mon-Fri 09:00-18:00", "2026-12-25, 2026-12-26", false},
+		{"Mon,Wed,Fri 10:00-12:30 UTC", "", false},
+		{"Sat-Mon 00:00-23:59", "", false},
+		{"", "", true},
+		{"Mon-Fri", "", true},
+		{"Mon-Xyz 09:00-18:00", "", true},
+		{"Mon-Fri 18:00-09:00", "", true},
+		{"Mon-Fri 9am-6pm", "", true},
+		{"Mon-Fri 09:00-18:00 Mars/Olympus", "", true},
+		{"Mon-Fri 09:00-18:00", "25.12.2026", true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.spec, func(t *testing.T) {
+			_, err := ParseBusinessHours(testCase.spec, testCase.holidays)
+			if testCase.expectError && err == nil {
+				t.Errorf("Expected error for spec %q, got nil", testCase.spec)
+			}
+			if !testCase.expectError && err != nil {
+				t.Errorf("Did not expect error for spec %q, got %v", testCase.spec, err)
+			}
+		})
+	}
+
+	calendar, _ := ParseBusinessHours("Sat-Mon 10:00-12:00", "")
+	for day, expected := range []bool{true, true, false, false, false, false, true} {
+		if calendar.Weekdays[day] != expected {
+			t.Errorf("Expected weekday %v working=%v", time.Weekday(day), expected)
+		}
+	}
+}
+
+func TestBusinessCalendarAdd(t *testing.T) {
+	calendar, err := ParseBusinessHours("Mon-Fri 09:00-18:00 Europe/Berlin", "2026-12-25")
+	if err != nil {
+		t.Fatalf("ParseBusinessHours returned error: %v", err)
+	}
+	berlin := calendar.Location
+	tests := []struct {
+		name     string
+		start    time.Time
+		duration time.Duration
+		expected time.Time
+	}{
+		{
+			name:     "within one day",
+			start:    time.Date(2026, 10, 12, 10, 0, 0, 0, berlin), // Monday
+			duration: 2 * time.Hour,
+			expected: time.Date(2026, 10, 12, 12, 0, 0, 0, berlin),
+		},
+		{
+			name:     "start before working hours",
+			start:    time.Date(2026, 10, 12, 6, 0, 0, 0, berlin),
+			duration: time.Hour,
+			expected: time.Date(2026, 10, 12, 10, 0, 0, 0, berlin),
+		},
+		{
+			name:     "16h over the weekend",
+			start:    time.Date(2026, 10, 16, 14, 0, 0, 0, berlin), // Friday
+			duration: 16 * time.Hour,
+			expected: time.Date(2026, 10, 20, 12, 0, 0, 0, berlin), // Tuesday
+		},
+		{
+			name:     "skips holiday",
+			start:    time.Date(2026, 12, 24, 17, 0, 0, 0, berlin), // Thursday before holiday
+			duration: 2 * time.Hour,
+			expected: time.Date(2026, 12, 28, 10, 0, 0, 0, berlin), // Monday
+		},
+		{
+			name:     "ends exactly at closing time",
+			start:    time.Date(2026, 10, 12, 9, 0, 0, 0, berlin),
+			duration: 9 * time.Hour,
+			expected: time.Date(2026, 10, 12, 18, 0, 0, 0, berlin),
+		},
+		{
+			name:     "zero duration",
+			start:    time.Date(2026, 10, 17, 3, 0, 0, 0, berlin),
+			expected: time.Date(2026, 10, 17, 3, 0, 0, 0, berlin),
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			result := calendar.Add(testCase.start.UTC(), testCase.duration)
+			if !result.Equal(testCase.expected) {
+				t.Errorf("Add() = %v, want %v", result.In(berlin), testCase.expected)
+			}
+			if result.Location() != time.UTC {
+				t.Errorf("Expected result in start location, got %v", result.Location())
+			}
+		})
+	}
+}
+
+func TestBusinessCalendarBetween(t *testing.T) {
+	calendar, err := ParseBusinessHours("Mon-Fri 09:00-18:00", "")
+	if err != nil {
+		t.Fatalf("ParseBusinessHours returned error: %v", err)
+	}
+	friday := time.Date(2026, 10, 16, 14, 0, 0, 0, time.UTC)
+	if between := calendar.Between(friday, friday.AddDate(0, 0, 3)); between != 9*time.Hour {
+		t.Errorf("Expected 9h from Friday to Monday afternoon, got %v", between)
+	}
+	if between := calendar.Between(friday, friday.Add(-time.Hour)); between != 0 {
+		t.Errorf("Expected 0 for reversed range, got %v", between)
+	}
+	start := time.Date(2026, 10, 12, 7, 0, 0, 0, time.UTC)
+	if between := calendar.Between(start, calendar.Add(start, 20*time.Hour)); between != 20*time.Hour {
+		t.Errorf("Expected Between to invert Add, got %v", between)
+	}
+}
+
+func TestWallClock(t *testing.T) {
+	start := time.Date(2026, 10, 16, 14, 0, 0, 0, time.UTC)
+	clock := WallClock{}
+	if result := clock.Add(start, 16*time.Hour); !result.Equal(start.Add(16 * time.Hour)) {
+		t.Errorf("Expected wall clock to count every hour, got %v", result)
+	}
+	if between := clock.Between(start, start.Add(-time.Hour)); between != 0 {
+		t.Errorf("Expected 0 for reversed range, got %v", between)
+	}
+}
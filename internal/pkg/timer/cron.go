@@ -0,0 +1,173 @@
+package timer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed 5-field cron expression: minute hour day-of-month month day-of-week
+type Schedule struct {
+	minute   uint64
+	hour     uint64
+	dom      uint64
+	month    uint64
+	dow      uint64
+	location *time.Location
+	// Day matches either field when both are restricted, as classic cron does
+	domStar bool
+	dowStar bool
+}
+
+type cronField struct {
+	min   int
+	max   int
+	names map[string]int
+}
+
+var (
+	minuteField = cronField{min: 0, max: 59}
+	hourField   = cronField{min: 0, max: 23}
+	domField    = cronField{min: 1, max: 31}
+	monthField  = cronField{min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dowField = cronField{min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// Upper bound for next fire search, a schedule like "0 0 30 2 *" never fires
+const maxScheduleYears = 5
+
+// ParseSchedule parses cron expression like "0 20 * * FRI".
+// Times are UTC unless the expression starts with "CRON_TZ=Europe/Berlin ".
+func ParseSchedule(spec string) (Schedule, error) {
+	schedule := Schedule{location: time.UTC}
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, "CRON_TZ=") {
+		zone, rest, _ := strings.Cut(strings.TrimPrefix(spec, "CRON_TZ="), " ")
+		location, err := time.LoadLocation(zone)
+		if err != nil {
+			return schedule, fmt.Errorf("cron timezone %q: %w", zone, err)
+		}
+		schedule.location = location
+		spec = rest
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return schedule, fmt.Errorf("cron expression %q must have 5 fields", spec)
+	}
+	var err error
+	if schedule.minute, err = minuteField.parse(fields[0]); err != nil {
+		return schedule, err
+	}
+	if schedule.hour, err = hourField.parse(fields[1]); err != nil {
+		return schedule, err
+	}
+	if schedule.dom, err = domField.parse(fields[2]); err != nil {
+		return schedule, err
+	}
+	if schedule.month, err = monthField.parse(fields[3]); err != nil {
+		return schedule, err
+	}
+	if schedule.dow, err = dowField.parse(fields[4]); err != nil {
+		return schedule, err
+	}
+	// 7 is another name for Sunday
+	if schedule.dow&(1<<7) != 0 {
+		schedule.dow |= 1
+	}
+	schedule.domStar = fields[2] == "*" || fields[2] == "?"
+	schedule.dowStar = fields[4] == "*" || fields[4] == "?"
+	return schedule, nil
+}
+
+func (f cronField) parse(spec string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(spec, ",") {
+		rangeSpec, stepSpec, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepSpec)
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("bad cron step in %q", part)
+			}
+		}
+		first, last := f.min, f.max
+		if rangeSpec != "*" && rangeSpec != "?" {
+			from, to, isRange := strings.Cut(rangeSpec, "-")
+			var err error
+			if first, err = f.value(from); err != nil {
+				return 0, err
+			}
+			last = first
+			if isRange {
+				if last, err = f.value(to); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				last = f.max
+			}
+		}
+		if first > last {
+			return 0, fmt.Errorf("bad cron range %q", part)
+		}
+		for value := first; value <= last; value += step {
+			bits |= 1 << value
+		}
+	}
+	return bits, nil
+}
+
+func (f cronField) value(spec string) (int, error) {
+	if value, ok := f.names[strings.ToLower(spec)]; ok {
+		return value, nil
+	}
+	value, err := strconv.Atoi(spec)
+	if err != nil {
+		return 0, fmt.Errorf("bad cron value %q", spec)
+	}
+	if value < f.min || value > f.max {
+		return 0, fmt.Errorf("cron value %d is out of range %d-%d", value, f.min, f.max)
+	}
+	return value, nil
+}
+
+func (s Schedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<t.Day()) != 0
+	dowMatch := s.dow&(1<<t.Weekday()) != 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// Next returns the first fire time strictly after the given time, zero time if there is none
+func (s Schedule) Next(after time.Time) time.Time {
+	t := after.In(s.location).Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(maxScheduleYears, 0, 0)
+	for t.Before(limit) {
+		if s.month&(1<<t.Month()) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, s.location)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, s.location)
+			continue
+		}
+		if s.hour&(1<<t.Hour()) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, s.location)
+			continue
+		}
+		if s.minute&(1<<t.Minute()) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t.In(after.Location())
+	}
+	return time.Time{}
+}
@@ -0,0 +1,77 @@
+package timer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	tests := []struct {
+		spec        string
+		expectError bool
+	}{
+		{"0 20 * * FRI", false},
+		{"*/15 9-17 * * 1-5", false},
+		{"0 0 1,15 JAN-JUN *", false},
+		{"CRON_TZ=Europe/Berlin 0 20 * * fri", false},
+		{"30 4 * * 7", false},
+		{"", true},
+		{"0 20 * *", true},
+		{"60 20 * * *", true},
+		{"0 20 * * FRIDAY", true},
+		{"0 20-10 * * *", true},
+		{"*/0 * * * *", true},
+		{"CRON_TZ=Mars/Olympus 0 20 * * *", true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.spec, func(t *testing.T) {
+			_, err := ParseSchedule(testCase.spec)
+			if testCase.expectError && err == nil {
+				t.Errorf("Expected error for spec %q, got nil", testCase.spec)
+			}
+			if !testCase.expectError && err != nil {
+				t.Errorf("Did not expect error for spec %q, got %v", testCase.spec, err)
+			}
+		})
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	// 2026-10-14 is Wednesday
+	after := time.Date(2026, 10, 14, 12, 34, 56, 0, time.UTC)
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	tests := []struct {
+		spec     string
+		after    time.Time
+		expected time.Time
+	}{
+		{"0 20 * * FRI", after, time.Date(2026, 10, 16, 20, 0, 0, 0, time.UTC)},
+		{"0 20 * * FRI", time.Date(2026, 10, 16, 20, 0, 0, 0, time.UTC), time.Date(2026, 10, 23, 20, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", after, time.Date(2026, 10, 14, 12, 45, 0, 0, time.UTC)},
+		{"0 9-17 * * 1-5", after, time.Date(2026, 10, 14, 13, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", after, time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 JAN *", after, time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"30 4 * * 7", after, time.Date(2026, 10, 18, 4, 30, 0, 0, time.UTC)},
+		// Day-of-month or day-of-week when both are restricted
+		{"0 0 20 * MON", after, time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)},
+		{"CRON_TZ=Europe/Berlin 0 20 * * FRI", after, time.Date(2026, 10, 16, 20, 0, 0, 0, berlin)},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.spec, func(t *testing.T) {
+			schedule, err := ParseSchedule(testCase.spec)
+			if err != nil {
+				t.Fatalf("ParseSchedule returned error: %v", err)
+			}
+			if next := schedule.Next(testCase.after); !next.Equal(testCase.expected) {
+				t.Errorf("Next() = %v, want %v", next, testCase.expected)
+			}
+		})
+	}
+
+	schedule, _ := ParseSchedule("0 0 30 2 *")
+	if next := schedule.Next(after); !next.IsZero() {
+		t.Errorf("Expected no fire time for February 30, got %v", next)
+	}
+}
@@ -0,0 +1,175 @@
+package timer
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	Day  = 24 * time.Hour
+	Week = 7 * Day
+)
+
+var durationUnits = map[string]time.Duration{
+	"ns": time.Nanosecond,
+	"us": time.Microsecond,
+	"µs": time.Microsecond,
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+	"d":  Day,
+	"w":  Week,
+}
+
+// ParseDuration parses Go durations extended with days and weeks ("7d", "1w2d12h")
+// and ISO 8601 durations ("P3DT4H", "P2W"). Years and months are rejected, they have no fixed length.
+func ParseDuration(s string) (time.Duration, error) {
+	if strings.HasPrefix(s, "P") || strings.HasPrefix(s, "-P") {
+		return parseISODuration(s)
+	}
+	input := s
+	negative := false
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		negative = s[0] == '-'
+		s = s[1:]
+	}
+	if s == "0" {
+		return 0, nil
+	}
+	if s == "" {
+		return 0, fmt.Errorf("invalid duration %q", input)
+	}
+	var total time.Duration
+	for s != "" {
+		number, unit, rest, err := nextDurationPart(s, false)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q: %w", input, err)
+		}
+		scale, ok := durationUnits[unit]
+		if !ok {
+			return 0, fmt.Errorf("invalid duration %q: unknown unit %q", input, unit)
+		}
+		if total, err = addDurationPart(total, number, scale); err != nil {
+			return 0, fmt.Errorf("invalid duration %q: %w", input, err)
+		}
+		s = rest
+	}
+	if negative {
+		return -total, nil
+	}
+	return total, nil
+}
+
+func parseISODuration(s string) (time.Duration, error) {
+	input := s
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "-"), "P")
+	datePart, timePart, hasTime := strings.Cut(s, "T")
+	if (datePart == "" && timePart == "") || (hasTime && timePart == "") {
+		return 0, fmt.Errorf("invalid ISO 8601 duration %q", input)
+	}
+	var total time.Duration
+	for _, part := range []struct {
+		spec  string
+		units map[string]time.Duration
+	}{
+		{datePart, map[string]time.Duration{"W": Week, "D": Day}},
+		{timePart, map[string]time.Duration{"H": time.Hour, "M": time.Minute, "S": time.Second}},
+	} {
+		for part.spec != "" {
+			number, unit, rest, err := nextDurationPart(part.spec, true)
+			if err != nil {
+				return 0, fmt.Errorf("invalid ISO 8601 duration %q: %w", input, err)
+			}
+			scale, ok := part.units[unit]
+			if !ok {
+				return 0, fmt.Errorf("invalid ISO 8601 duration %q: unsupported unit %q", input, unit)
+			}
+			if total, err = addDurationPart(total, number, scale); err != nil {
+				return 0, fmt.Errorf("invalid ISO 8601 duration %q: %w", input, err)
+			}
+			part.spec = rest
+		}
+	}
+	if negative {
+		return -total, nil
+	}
+	return total, nil
+}
+
+// nextDurationPart splits "12.5h30m" into "12.5", "h" and "30m"
+func nextDurationPart(s string, singleLetterUnit bool) (string, string, string, error) {
+	i := 0
+	for i < len(s) && (s[i] == '.' || (s[i] >= '0' && s[i] <= '9')) {
+		i++
+	}
+	if i == 0 {
+		return "", "", "", fmt.Errorf("missing number before %q", s)
+	}
+	j := i
+	for j < len(s) && s[j] != '.' && (s[j] < '0' || s[j] > '9') {
+		j++
+		if singleLetterUnit {
+			break
+		}
+	}
+	if j == i {
+		return "", "", "", fmt.Errorf("missing unit after %q", s[:i])
+	}
+	return s[:i], s[i:j], s[j:], nil
+}
+
+// addDurationPart adds number * scale to total, whole numbers are counted exactly
+func addDurationPart(total time.Duration, number string, scale time.Duration) (time.Duration, error) {
+	var part time.Duration
+	if whole, err := strconv.ParseInt(number, 10, 64); err == nil {
+		if whole > math.MaxInt64/int64(scale) {
+			return 0, fmt.Errorf("overflow")
+		}
+		part = time.Duration(whole) * scale
+	} else {
+		fraction, err := strconv.ParseFloat(number, 64)
+		if err != nil {
+			return 0, fmt.Errorf("bad number %q", number)
+		}
+		if fraction*float64(scale) >= math.MaxInt64 {
+			return 0, fmt.Errorf("overflow")
+		}
+		part = time.Duration(fraction * float64(scale))
+	}
+	if total > math.MaxInt64-part {
+		return 0, fmt.Errorf("overflow")
+	}
+	return total + part, nil
+}
+
+// FormatDuration formats duration in the ParseDuration grammar with days, like "7d" or "1d2h30m".
+// Parsing the result returns the same duration.
+func FormatDuration(d time.Duration) string {
+	if d == 0 {
+		return "0s"
+	}
+	var builder strings.Builder
+	if d < 0 {
+		builder.WriteString("-")
+		d = -d
+	}
+	for _, unit := range []struct {
+		name  string
+		scale time.Duration
+	}{{"d", Day}, {"h", time.Hour}, {"m", time.Minute}, {"s", time.Second}} {
+		if d >= unit.scale {
+			fmt.Fprintf(&builder, "%d%s", d/unit.scale, unit.name)
+			d %= unit.scale
+		}
+	}
+	if d > 0 {
+		// Sub-second remainder, Go formats it as ms, µs or ns
+		builder.WriteString(d.String())
+	}
+	return builder.String()
+}
@@ -0,0 +1,90 @@
+package timer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    time.Duration
+		expectError bool
+	}{
+		// Go syntax keeps working
+		{input: "30m", expected: 30 * time.Minute},
+		{input: "1h30m", expected: 90 * time.Minute},
+		{input: "1.5h", expected: 90 * time.Minute},
+		{input: "500ms", expected: 500 * time.Millisecond},
+		{input: "0", expected: 0},
+		{input: "-2h", expected: -2 * time.Hour},
+		// Days and weeks
+		{input: "7d", expected: 168 * time.Hour},
+		{input: "1w", expected: 168 * time.Hour},
+		{input: "1w2d12h", expected: 228 * time.Hour},
+		{input: "0.5d", expected: 12 * time.Hour},
+		// ISO 8601
+		{input: "P3DT4H", expected: 76 * time.Hour},
+		{input: "P2W", expected: 336 * time.Hour},
+		{input: "PT90M", expected: 90 * time.Minute},
+		{input: "PT0.5S", expected: 500 * time.Millisecond},
+		{input: "P1D", expected: 24 * time.Hour},
+		// Invalid
+		{input: "", expectError: true},
+		{input: "7", expectError: true},
+		{input: "d", expectError: true},
+		{input: "7days", expectError: true},
+		{input: "1y", expectError: true},
+		{input: "P", expectError: true},
+		{input: "PT", expectError: true},
+		{input: "P1M", expectError: true},
+		{input: "P1Y", expectError: true},
+		{input: "P1H", expectError: true},
+		{input: "99999999999w", expectError: true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.input, func(t *testing.T) {
+			result, err := ParseDuration(testCase.input)
+			if testCase.expectError {
+				if err == nil {
+					t.Errorf("Expected error for input %q, got %v", testCase.input, result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Did not expect error for input %q, got %v", testCase.input, err)
+			}
+			if result != testCase.expected {
+				t.Errorf("ParseDuration(%q) = %v, want %v", testCase.input, result, testCase.expected)
+			}
+		})
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		input    time.Duration
+		expected string
+	}{
+		{0, "0s"},
+		{168 * time.Hour, "7d"},
+		{26*time.Hour + 30*time.Minute, "1d2h30m"},
+		{90 * time.Second, "1m30s"},
+		{1500 * time.Millisecond, "1s500ms"},
+		{-2 * time.Hour, "-2h"},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.expected, func(t *testing.T) {
+			result := FormatDuration(testCase.input)
+			if result != testCase.expected {
+				t.Errorf("FormatDuration(%v) = %q, want %q", testCase.input, result, testCase.expected)
+			}
+			parsed, err := ParseDuration(result)
+			if err != nil || parsed != testCase.input {
+				t.Errorf("Expected %q to parse back to %v, got %v, %v", result, testCase.input, parsed, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package timer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// QuietHours is a daily window without notifications, it may wrap midnight like "22:00-07:00"
+type QuietHours struct {
+	StartMinute int // minutes after midnight
+	EndMinute   int
+	Location    *time.Location
+}
+
+// ParseQuietHours parses spec like "22:00-07:00 Europe/Berlin", timezone defaults to UTC
+func ParseQuietHours(spec string) (QuietHours, error) {
+	quiet := QuietHours{Location: time.UTC}
+	fields := strings.Fields(spec)
+	if len(fields) < 1 || len(fields) > 2 {
+		return quiet, fmt.Errorf("quiet hours %q must look like '22:00-07:00 Europe/Berlin'", spec)
+	}
+	from, to, ok := strings.Cut(fields[0], "-")
+	if !ok {
+		return quiet, fmt.Errorf("quiet hours %q must look like '22:00-07:00'", fields[0])
+	}
+	start, err := time.Parse("15:04", from)
+	if err != nil {
+		return quiet, fmt.Errorf("quiet hours start %q: %w", from, err)
+	}
+	end, err := time.Parse("15:04", to)
+	if err != nil {
+		return quiet, fmt.Errorf("quiet hours end %q: %w", to, err)
+	}
+	quiet.StartMinute = start.Hour()*60 + start.Minute()
+	quiet.EndMinute = end.Hour()*60 + end.Minute()
+	if quiet.StartMinute == quiet.EndMinute {
+		return quiet, fmt.Errorf("quiet hours %q must not be empty", spec)
+	}
+	if len(fields) == 2 {
+		location, err := time.LoadLocation(fields[1])
+		if err != nil {
+			return quiet, fmt.Errorf("quiet hours timezone %q: %w", fields[1], err)
+		}
+		quiet.Location = location
+	}
+	return quiet, nil
+}
+
+// In returns the same window in another timezone, so "22:00-07:00" means local night everywhere
+func (q QuietHours) In(location *time.Location) QuietHours {
+	q.Location = location
+	return q
+}
+
+func (q QuietHours) wraps() bool {
+	return q.EndMinute < q.StartMinute
+}
+
+func (q QuietHours) Contains(t time.Time) bool {
+	local := t.In(q.Location)
+	minute := local.Hour()*60 + local.Minute()
+	if q.wraps() {
+		return minute >= q.StartMinute || minute < q.EndMinute
+	}
+	return minute >= q.StartMinute && minute < q.EndMinute
+}
+
+// End returns when the quiet window containing t ends, t itself when t is outside the window
+func (q QuietHours) End(t time.Time) time.Time {
+	if !q.Contains(t) {
+		return t
+	}
+	local := t.In(q.Location)
+	year, month, day := local.Date()
+	if q.wraps() && local.Hour()*60+local.Minute() >= q.StartMinute {
+		day++
+	}
+	return time.Date(year, month, day, 0, q.EndMinute, 0, 0, q.Location).In(t.Location())
+}
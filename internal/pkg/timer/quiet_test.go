@@ -0,0 +1,92 @@
+package timer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseQuietHours(t *testing.T) {
+	tests := []struct {
+		spec        string
+		expectError bool
+	}{
+		{"22:00-07:00 Europe/Berlin", false},
+		{"12:00-13:30", false},
+		{"", true},
+		{"22:00", true},
+		{"22:00-22:00", true},
+		{"10pm-7am", true},
+		{"22:00-07:00 Mars/Olympus", true},
+		{"22:00-07:00 UTC extra", true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.spec, func(t *testing.T) {
+			_, err := ParseQuietHours(testCase.spec)
+			if testCase.expectError && err == nil {
+				t.Errorf("Expected error for spec %q, got nil", testCase.spec)
+			}
+			if !testCase.expectError && err != nil {
+				t.Errorf("Did not expect error for spec %q, got %v", testCase.spec, err)
+			}
+		})
+	}
+}
+
+func TestQuietHoursEnd(t *testing.T) {
+	night, err := ParseQuietHours("22:00-07:00 Europe/Berlin")
+	if err != nil {
+		t.Fatalf("ParseQuietHours returned error: %v", err)
+	}
+	berlin := night.Location
+	lunch, _ := ParseQuietHours("12:00-13:30")
+	tests := []struct {
+		name     string
+		quiet    QuietHours
+		at       time.Time
+		expected time.Time
+	}{
+		{
+			name:     "late evening ends next morning",
+			quiet:    night,
+			at:       time.Date(2026, 10, 16, 23, 15, 0, 0, berlin),
+			expected: time.Date(2026, 10, 17, 7, 0, 0, 0, berlin),
+		},
+		{
+			name:     "early morning ends the same day",
+			quiet:    night,
+			at:       time.Date(2026, 10, 17, 3, 0, 0, 0, berlin),
+			expected: time.Date(2026, 10, 17, 7, 0, 0, 0, berlin),
+		},
+		{
+			name:     "outside the window",
+			quiet:    night,
+			at:       time.Date(2026, 10, 17, 7, 0, 0, 0, berlin),
+			expected: time.Date(2026, 10, 17, 7, 0, 0, 0, berlin),
+		},
+		{
+			name:     "window within a day",
+			quiet:    lunch,
+			at:       time.Date(2026, 10, 16, 12, 30, 0, 0, time.UTC),
+			expected: time.Date(2026, 10, 16, 13, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "same window in another timezone",
+			quiet:    night.In(time.UTC),
+			at:       time.Date(2026, 10, 16, 23, 15, 0, 0, berlin), // 21:15 UTC
+			expected: time.Date(2026, 10, 16, 23, 15, 0, 0, berlin),
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			result := testCase.quiet.End(testCase.at.UTC())
+			if !result.Equal(testCase.expected) {
+				t.Errorf("End() = %v, want %v", result, testCase.expected)
+			}
+			if result.Location() != time.UTC {
+				t.Errorf("Expected result in the location of t, got %v", result.Location())
+			}
+		})
+	}
+}
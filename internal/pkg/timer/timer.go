@@ -4,21 +4,62 @@ import (
 	"time"
 )
 
-// Variable ttlRemoval — string with format "360m", "24h" and so on
+// Variable ttlRemoval — string with format "360m", "24h", "7d", "P1DT12H" and so on
+// anchorTime is the moment the countdown starts from (creation or last update)
 // Function returns 0s or current ttl
-func GetDuration(creationTime time.Time, ttl string, factor float64) (time.Duration, error) {
-	baseTtlDuration, err := time.ParseDuration(ttl)
+func GetDuration(anchorTime time.Time, ttl string, factor float64) (time.Duration, error) {
+	removalTime, err := GetDeadline(WallClock{}, anchorTime, ttl, factor)
 	if err != nil {
 		return 0, err
 	}
+	return GetRemaining(removalTime), nil
+}
+
+// GetDeadline returns the moment when ttl * factor of clock time runs out, counted from anchorTime
+func GetDeadline(clock Clock, anchorTime time.Time, ttl string, factor float64) (time.Time, error) {
+	baseTtlDuration, err := ParseDuration(ttl)
+	if err != nil {
+		return time.Time{}, err
+	}
 	ttlDuration := time.Duration(float64(baseTtlDuration) * factor)
-	removalTime := creationTime.UTC().Add(ttlDuration)
+	return clock.Add(anchorTime.UTC(), ttlDuration), nil
+}
+
+// GetRemaining returns time left until deadline or 0s if it already passed
+func GetRemaining(deadline time.Time) time.Duration {
 	now := time.Now().UTC()
+	if now.After(deadline) || now.Equal(deadline) {
+		return 0
+	}
+	return deadline.Sub(now)
+}
 
-	if now.After(removalTime) || now.Equal(removalTime) {
-		return 0, nil
+// GetFactorTime returns the point at factor of the clock time from start to end
+func GetFactorTime(clock Clock, start, end time.Time, factor float64) time.Time {
+	return clock.Add(start, time.Duration(float64(clock.Between(start, end))*factor))
+}
+
+// GetReplenishedAnchor returns the countdown anchor after an activity update.
+// An update counts only if it arrives after ratio * ttl has elapsed since the current anchor;
+// it then restores ratio * ttl, so the anchor moves forward by that much and the next update
+// has to wait for the threshold again. Earlier updates are ignored.
+// Ratio <= 0 accepts every update and restarts the countdown from it.
+func GetReplenishedAnchor(clock Clock, anchorTime time.Time, updateTime time.Time, ttl string, ratio float64) (time.Time, error) {
+	baseTtlDuration, err := ParseDuration(ttl)
+	if err != nil {
+		return anchorTime, err
+	}
+	if !updateTime.After(anchorTime) {
+		return anchorTime, nil
 	}
-	return removalTime.Sub(now), nil
+	if ratio <= 0 {
+		return updateTime, nil
+	}
+	threshold := clock.Add(anchorTime, time.Duration(float64(baseTtlDuration)*ratio))
+	if updateTime.Before(threshold) {
+		return anchorTime, nil
+	}
+	return threshold, nil
 }
 
 func GetEntityAge(creationTime time.Time) time.Duration {
@@ -41,12 +82,19 @@ func GetMaxTime(t1, t2 time.Time) time.Time {
 	return t2
 }
 
+func GetMinTime(t1, t2 time.Time) time.Time {
+	if t1.Before(t2) {
+		return t1
+	}
+	return t2
+}
+
 func GetMaxDuration(a, b string) (string, error) {
-	aDuration, err := time.ParseDuration(a)
+	aDuration, err := ParseDuration(a)
 	if err != nil {
 		return "", err
 	}
-	bDuration, err := time.ParseDuration(b)
+	bDuration, err := ParseDuration(b)
 	if err != nil {
 		return "", err
 	}
@@ -55,3 +103,18 @@ func GetMaxDuration(a, b string) (string, error) {
 	}
 	return b, nil
 }
+
+func GetMinDuration(a, b string) (string, error) {
+	aDuration, err := ParseDuration(a)
+	if err != nil {
+		return "", err
+	}
+	bDuration, err := ParseDuration(b)
+	if err != nil {
+		return "", err
+	}
+	if aDuration < bDuration {
+		return a, nil
+	}
+	return b, nil
+}
@@ -85,6 +85,162 @@ func TestGetDuration(t *testing.T) {
 	})
 }
 
+func TestGetDeadline(t *testing.T) {
+	anchorTime := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	deadline, err := GetDeadline(WallClock{}, anchorTime, "2h", 0.5)
+	if err != nil {
+		t.Fatalf("GetDeadline returned error: %v", err)
+	}
+	if !deadline.Equal(anchorTime.Add(time.Hour)) {
+		t.Errorf("Expected deadline %v, got %v", anchorTime.Add(time.Hour), deadline)
+	}
+	if _, err := GetDeadline(WallClock{}, anchorTime, "bad", 1); err == nil {
+		t.Error("Expected error for invalid TTL format, but got none")
+	}
+}
+
+func TestGetRemaining(t *testing.T) {
+	if remaining := GetRemaining(time.Now().Add(-time.Minute)); remaining != 0 {
+		t.Errorf("Expected 0 for passed deadline, got %v", remaining)
+	}
+	remaining := GetRemaining(time.Now().Add(time.Hour))
+	if remaining < time.Hour-time.Second || remaining > time.Hour {
+		t.Errorf("Expected remaining around 1h, got %v", remaining)
+	}
+}
+
+func TestGetFactorTime(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(10 * time.Hour)
+	if result := GetFactorTime(WallClock{}, start, end, 0.8); !result.Equal(start.Add(8 * time.Hour)) {
+		t.Errorf("Expected %v, got %v", start.Add(8*time.Hour), result)
+	}
+}
+
+func TestGetReplenishedAnchor(t *testing.T) {
+	creationTime := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		updateTime  time.Time
+		ttl         string
+		ratio       float64
+		expected    time.Time
+		expectError bool
+	}{
+		{
+			name:       "update after threshold",
+			updateTime: creationTime.Add(50 * time.Minute),
+			ttl:        "1h",
+			ratio:      0.75,
+			expected:   creationTime.Add(45 * time.Minute),
+		},
+		{
+			name:       "update before threshold",
+			updateTime: creationTime.Add(30 * time.Minute),
+			ttl:        "1h",
+			ratio:      0.75,
+			expected:   creationTime,
+		},
+		{
+			name:       "update after expiry restores only ratio",
+			updateTime: creationTime.Add(2 * time.Hour),
+			ttl:        "1h",
+			ratio:      0.75,
+			expected:   creationTime.Add(45 * time.Minute),
+		},
+		{
+			name:       "zero ratio accepts any update",
+			updateTime: creationTime.Add(time.Minute),
+			ttl:        "1h",
+			ratio:      0,
+			expected:   creationTime.Add(time.Minute),
+		},
+		{
+			name:       "ratio above one waits for expiry",
+			updateTime: creationTime.Add(80 * time.Minute),
+			ttl:        "1h",
+			ratio:      1.5,
+			expected:   creationTime,
+		},
+		{
+			name:       "update before creation",
+			updateTime: creationTime.Add(-time.Hour),
+			ttl:        "1h",
+			ratio:      0,
+			expected:   creationTime,
+		},
+		{
+			name:        "invalid ttl",
+			updateTime:  creationTime.Add(time.Hour),
+			ttl:         "bad",
+			ratio:       0.5,
+			expected:    creationTime,
+			expectError: true,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			result, err := GetReplenishedAnchor(WallClock{}, creationTime, testCase.updateTime, testCase.ttl, testCase.ratio)
+			if testCase.expectError && err == nil {
+				t.Errorf("Expected error for ttl %q, got nil", testCase.ttl)
+			}
+			if !testCase.expectError && err != nil {
+				t.Errorf("Did not expect error, got %v", err)
+			}
+			if !result.Equal(testCase.expected) {
+				t.Errorf("GetReplenishedAnchor() = %v, want %v", result, testCase.expected)
+			}
+		})
+	}
+}
+
+func TestGetReplenishedAnchorRepeatedTouches(t *testing.T) {
+	creationTime := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		ratio    float64
+		touches  []time.Duration
+		expected []time.Duration
+	}{
+		{
+			// The second touch is measured from the moved anchor, not from creation
+			name:     "touches shortly after threshold",
+			ratio:    0.9,
+			touches:  []time.Duration{56 * time.Minute, 60 * time.Minute, 10 * time.Hour},
+			expected: []time.Duration{54 * time.Minute, 54 * time.Minute, 108 * time.Minute},
+		},
+		{
+			name:     "regular touches",
+			ratio:    0.5,
+			touches:  []time.Duration{20 * time.Minute, 40 * time.Minute, 70 * time.Minute, 80 * time.Minute, 95 * time.Minute},
+			expected: []time.Duration{0, 30 * time.Minute, 60 * time.Minute, 60 * time.Minute, 90 * time.Minute},
+		},
+		{
+			name:     "zero ratio restarts from every touch",
+			ratio:    0,
+			touches:  []time.Duration{time.Minute, 2 * time.Minute},
+			expected: []time.Duration{time.Minute, 2 * time.Minute},
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			anchor := creationTime
+			for i, touch := range testCase.touches {
+				var err error
+				anchor, err = GetReplenishedAnchor(WallClock{}, anchor, creationTime.Add(touch), "1h", testCase.ratio)
+				if err != nil {
+					t.Fatalf("Did not expect error, got %v", err)
+				}
+				if expected := creationTime.Add(testCase.expected[i]); !anchor.Equal(expected) {
+					t.Errorf("Touch at %s: expected anchor %v, got %v", touch, expected, anchor)
+				}
+			}
+		})
+	}
+}
+
 func TestGetEntityAge(t *testing.T) {
 	currentTime := time.Now().UTC()
 	t.Run("just_created", func(t *testing.T) {
@@ -192,6 +348,16 @@ func TestGetMaxTime(t *testing.T) {
 	}
 }
 
+func TestGetMinTime(t *testing.T) {
+	currentTime := time.Now()
+	if result := GetMinTime(currentTime, currentTime.Add(time.Hour)); !result.Equal(currentTime) {
+		t.Errorf("GetMinTime() = %v, but %v expected", result, currentTime)
+	}
+	if result := GetMinTime(currentTime.Add(time.Hour), currentTime); !result.Equal(currentTime) {
+		t.Errorf("GetMinTime() = %v, but %v expected", result, currentTime)
+	}
+}
+
 func TestGetMaxDuration(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -218,6 +384,12 @@ func TestGetMaxDuration(t *testing.T) {
 			b:              "45m",
 			expectedResult: "45m",
 		},
+		{
+			name:           "days against hours",
+			a:              "7d",
+			b:              "100h",
+			expectedResult: "7d",
+		},
 		{
 			name:        "invalid a",
 			a:           "bad",
@@ -256,3 +428,36 @@ func TestGetMaxDuration(t *testing.T) {
 		})
 	}
 }
+
+func TestGetMinDuration(t *testing.T) {
+	tests := []struct {
+		name           string
+		a              string
+		b              string
+		expectedResult string
+		expectError    bool
+	}{
+		{name: "a less than b", a: "30m", b: "1h", expectedResult: "30m"},
+		{name: "b less than a", a: "1d", b: "2h", expectedResult: "2h"},
+		{name: "invalid a", a: "bad", b: "1h", expectError: true},
+		{name: "invalid b", a: "1h", b: "bad", expectError: true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			result, err := GetMinDuration(testCase.a, testCase.b)
+			if testCase.expectError {
+				if err == nil {
+					t.Errorf("Expected error for input (%q, %q), got nil", testCase.a, testCase.b)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Did not expect error for input (%q, %q), got %v", testCase.a, testCase.b, err)
+			}
+			if result != testCase.expectedResult {
+				t.Errorf("GetMinDuration(%q, %q) = %q, want %q", testCase.a, testCase.b, result, testCase.expectedResult)
+			}
+		})
+	}
+}